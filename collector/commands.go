@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/collector"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdacomponents"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+const usage = `Usage: %s [-v] [command]
+
+Without command, the binary runs as a Lambda extension. The commands check the collector configuration outside of
+Lambda, with the same resolver and converters as the extension:
+
+  validate [-config uri]      validates the configuration, and exits with a non-zero code if it is invalid
+  print-config [-config uri]  prints the effective configuration, after the Lambda converters
+  components                  lists the components compiled into this build
+
+The configuration is read from -config, or from OPENTELEMETRY_COLLECTOR_CONFIG_URI. Several URIs are separated by ";".
+
+`
+
+// offlineExtensionID stands in for the ID Lambda assigns to the extension when it registers, which components such as
+// the telemetryapireceiver require.
+const offlineExtensionID = "offline"
+
+// nopNotifier is the lifecycle notifier of the commands, which run outside of Lambda and have no lifecycle.
+type nopNotifier struct{}
+
+func (nopNotifier) AddListener(lambdalifecycle.Listener) {}
+
+// runCommand runs the command in args outside of Lambda, and returns the exit code of the binary.
+func runCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	name := args[0]
+	if !slices.Contains([]string{"validate", "print-config", "components"}, name) {
+		fmt.Fprintf(stderr, "unknown command %q\n", name)
+		fmt.Fprintf(stderr, usage, os.Args[0])
+		return 2
+	}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configURI := flags.String("config", "", "the configuration URIs, separated by \";\"")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *configURI != "" {
+		if err := os.Setenv("OPENTELEMETRY_COLLECTOR_CONFIG_URI", *configURI); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	// Validating the configuration creates the components, some of which register with the lifecycle notifier.
+	lambdalifecycle.SetNotifier(nopNotifier{})
+	logger := initLogger(zapcore.AddSync(stderr))
+	factories, err := lambdacomponents.Components(offlineExtensionID)
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid components: %v\n", err)
+		return 1
+	}
+
+	switch name {
+	case "validate":
+		if err = collector.NewCollector(logger, factories, Version).Validate(ctx); err != nil {
+			fmt.Fprintf(stderr, "invalid configuration: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, "the configuration is valid")
+	case "print-config":
+		effective, err := collector.NewCollector(logger, factories, Version).ResolveEffectiveConfig(ctx)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid configuration: %v\n", err)
+			return 1
+		}
+		_, _ = stdout.Write(effective)
+	case "components":
+		printComponents(stdout, "receivers", factories.Receivers)
+		printComponents(stdout, "processors", factories.Processors)
+		printComponents(stdout, "exporters", factories.Exporters)
+		printComponents(stdout, "extensions", factories.Extensions)
+		printComponents(stdout, "connectors", factories.Connectors)
+	}
+	_ = logger.Sync()
+	return 0
+}
+
+func printComponents[F any](w io.Writer, kind string, factories map[component.Type]F) {
+	names := make([]string, 0, len(factories))
+	for typ := range factories {
+		names = append(names, typ.String())
+	}
+	slices.Sort(names)
+	fmt.Fprintf(w, "%s:\n", kind)
+	for _, name := range names {
+		fmt.Fprintf(w, "  - %s\n", name)
+	}
+}
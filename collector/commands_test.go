@@ -0,0 +1,99 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLogzioConfig(t *testing.T) {
+	t.Setenv("OPENTELEMETRY_COLLECTOR_CONFIG_URI", "")
+	require.NoError(t, os.Unsetenv("OPENTELEMETRY_COLLECTOR_CONFIG_URI"))
+	t.Setenv("LOGZIO_TRACES_TOKEN", "traces-token")
+	t.Setenv("LOGZIO_METRICS_TOKEN", "metrics-token")
+	t.Setenv("LOGZIO_LOGS_TOKEN", "")
+	t.Setenv("LOGZIO_REGION", "eu")
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, runCommand(context.Background(), []string{"validate"}, &stdout, &stderr), stderr.String())
+	require.Equal(t, 0, runCommand(context.Background(), []string{"print-config"}, &stdout, &stderr), stderr.String())
+	assert.Contains(t, stdout.String(), "listener-eu.logz.io")
+	assert.NotContains(t, stdout.String(), "logzio/logs")
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Setenv("OPENTELEMETRY_COLLECTOR_CONFIG_URI", "")
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte(`
+receivers:
+  otlp:
+    protocols:
+      http:
+        endpoint: localhost:4318
+  telemetryapireceiver:
+processors:
+  batch:
+exporters:
+  debug:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp, telemetryapireceiver]
+      processors: [batch]
+      exporters: [debug]
+`), 0o600))
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte(`
+receivers:
+  otlp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [unknown]
+`), 0o600))
+
+	for _, tc := range []struct {
+		name        string
+		args        []string
+		code        int
+		contains    string
+		notContains string
+	}{
+		{name: "valid", args: []string{"validate", "-config", valid}, code: 0, contains: "valid"},
+		{name: "invalid", args: []string{"validate", "-config", invalid}, code: 1},
+		{name: "print config", args: []string{"print-config", "-config", valid}, code: 0, contains: "- decouple", notContains: "lambda_readiness"},
+		{name: "components", args: []string{"components"}, code: 0, contains: "  - telemetryapireceiver"},
+		{name: "unknown command", args: []string{"unknown"}, code: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runCommand(context.Background(), tc.args, &stdout, &stderr)
+			assert.Equal(t, tc.code, code, stderr.String())
+			assert.Contains(t, stdout.String(), tc.contains)
+			if tc.notContains != "" {
+				assert.NotContains(t, stdout.String(), tc.notContains)
+			}
+		})
+	}
+}
@@ -19,7 +19,7 @@ replace github.com/open-telemetry/opentelemetry-lambda/collector/receiver/teleme
 replace cloud.google.com/go => cloud.google.com/go v0.107.0
 
 require (
-	github.com/golang-collections/go-datastructures v0.0.0-20150211160725-59788d5eb259
+	github.com/aws/aws-sdk-go-v2/service/ssm v1.61.0
 	github.com/google/go-cmp v0.7.0
 	github.com/open-telemetry/opentelemetry-collector-contrib/confmap/provider/s3provider v0.130.0
 	github.com/open-telemetry/opentelemetry-collector-contrib/confmap/provider/secretsmanagerprovider v0.130.0
@@ -60,9 +60,9 @@ require (
 	github.com/alecthomas/units v0.0.0-20240927000941-0f3dac36c52b // indirect
 	github.com/antchfx/xmlquery v1.4.4 // indirect
 	github.com/antchfx/xpath v1.3.4 // indirect
-	github.com/aws/aws-sdk-go-v2 v1.37.0 // indirect
+	github.com/aws/aws-sdk-go-v2 v1.37.0
 	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.7.0 // indirect
-	github.com/aws/aws-sdk-go-v2/config v1.30.0 // indirect
+	github.com/aws/aws-sdk-go-v2/config v1.30.0
 	github.com/aws/aws-sdk-go-v2/credentials v1.18.0 // indirect
 	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.17.0 // indirect
 	github.com/aws/aws-sdk-go-v2/internal/configsources v1.4.0 // indirect
@@ -133,8 +133,8 @@ require (
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/mwitkow/go-conntrack v0.0.0-20190716064945-2f068394615f // indirect
 	github.com/open-telemetry/opentelemetry-collector-contrib/connector/spanmetricsconnector v0.130.0 // indirect
-	github.com/open-telemetry/opentelemetry-collector-contrib/exporter/logzioexporter v0.130.0 // indirect
-	github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusremotewriteexporter v0.130.0 // indirect
+	github.com/open-telemetry/opentelemetry-collector-contrib/exporter/logzioexporter v0.130.0
+	github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusremotewriteexporter v0.130.0
 	github.com/open-telemetry/opentelemetry-collector-contrib/extension/basicauthextension v0.130.0 // indirect
 	github.com/open-telemetry/opentelemetry-collector-contrib/extension/sigv4authextension v0.130.0 // indirect
 	github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal v0.130.0 // indirect
@@ -149,9 +149,9 @@ require (
 	github.com/open-telemetry/opentelemetry-collector-contrib/processor/attributesprocessor v0.130.0 // indirect
 	github.com/open-telemetry/opentelemetry-collector-contrib/processor/filterprocessor v0.130.0 // indirect
 	github.com/open-telemetry/opentelemetry-collector-contrib/processor/probabilisticsamplerprocessor v0.130.0 // indirect
-	github.com/open-telemetry/opentelemetry-collector-contrib/processor/resourceprocessor v0.130.0 // indirect
+	github.com/open-telemetry/opentelemetry-collector-contrib/processor/resourceprocessor v0.130.0
 	github.com/open-telemetry/opentelemetry-lambda/collector/processor/coldstartprocessor v0.98.0 // indirect
-	github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor v0.0.0-20250728180610-2945d3555cc8 // indirect
+	github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor v0.0.0-20250728180610-2945d3555cc8
 	github.com/open-telemetry/opentelemetry-lambda/collector/receiver/telemetryapireceiver v0.98.0 // indirect
 	github.com/pierrec/lz4/v4 v4.1.22 // indirect
 	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c // indirect
@@ -181,8 +181,8 @@ require (
 	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
 	go.opentelemetry.io/collector v0.130.1 // indirect
 	go.opentelemetry.io/collector/client v1.36.1 // indirect
-	go.opentelemetry.io/collector/component/componentstatus v0.130.1 // indirect
-	go.opentelemetry.io/collector/component/componenttest v0.130.1 // indirect
+	go.opentelemetry.io/collector/component/componentstatus v0.130.1
+	go.opentelemetry.io/collector/component/componenttest v0.130.1
 	go.opentelemetry.io/collector/config/configauth v0.130.1 // indirect
 	go.opentelemetry.io/collector/config/configcompression v1.36.1 // indirect
 	go.opentelemetry.io/collector/config/configgrpc v0.130.1 // indirect
@@ -203,39 +203,39 @@ require (
 	go.opentelemetry.io/collector/consumer/consumererror/xconsumererror v0.130.1 // indirect
 	go.opentelemetry.io/collector/consumer/consumertest v0.130.1 // indirect
 	go.opentelemetry.io/collector/consumer/xconsumer v0.130.1 // indirect
-	go.opentelemetry.io/collector/exporter v0.130.1 // indirect
-	go.opentelemetry.io/collector/exporter/debugexporter v0.130.1 // indirect
+	go.opentelemetry.io/collector/exporter v0.130.1
+	go.opentelemetry.io/collector/exporter/debugexporter v0.130.1
 	go.opentelemetry.io/collector/exporter/exporterhelper/xexporterhelper v0.130.1 // indirect
-	go.opentelemetry.io/collector/exporter/exportertest v0.130.1 // indirect
-	go.opentelemetry.io/collector/exporter/otlpexporter v0.130.1 // indirect
-	go.opentelemetry.io/collector/exporter/otlphttpexporter v0.130.1 // indirect
-	go.opentelemetry.io/collector/exporter/xexporter v0.130.1 // indirect
-	go.opentelemetry.io/collector/extension v1.36.1 // indirect
+	go.opentelemetry.io/collector/exporter/exportertest v0.130.1
+	go.opentelemetry.io/collector/exporter/otlpexporter v0.130.1
+	go.opentelemetry.io/collector/exporter/otlphttpexporter v0.130.1
+	go.opentelemetry.io/collector/exporter/xexporter v0.130.1
+	go.opentelemetry.io/collector/extension v1.36.1
 	go.opentelemetry.io/collector/extension/extensionauth v1.36.1 // indirect
 	go.opentelemetry.io/collector/extension/extensioncapabilities v0.130.1 // indirect
 	go.opentelemetry.io/collector/extension/extensionmiddleware v0.130.1 // indirect
-	go.opentelemetry.io/collector/extension/extensiontest v0.130.1 // indirect
+	go.opentelemetry.io/collector/extension/extensiontest v0.130.1
 	go.opentelemetry.io/collector/extension/xextension v0.130.1 // indirect
 	go.opentelemetry.io/collector/featuregate v1.36.1 // indirect
 	go.opentelemetry.io/collector/internal/fanoutconsumer v0.130.1 // indirect
 	go.opentelemetry.io/collector/internal/memorylimiter v0.130.1 // indirect
 	go.opentelemetry.io/collector/internal/sharedcomponent v0.130.1 // indirect
 	go.opentelemetry.io/collector/internal/telemetry v0.130.1 // indirect
-	go.opentelemetry.io/collector/pdata v1.36.1 // indirect
+	go.opentelemetry.io/collector/pdata v1.36.1
 	go.opentelemetry.io/collector/pdata/pprofile v0.130.1 // indirect
 	go.opentelemetry.io/collector/pdata/testdata v0.130.1 // indirect
 	go.opentelemetry.io/collector/pdata/xpdata v0.130.1 // indirect
-	go.opentelemetry.io/collector/pipeline v0.130.1 // indirect
+	go.opentelemetry.io/collector/pipeline v0.130.1
 	go.opentelemetry.io/collector/pipeline/xpipeline v0.130.1 // indirect
-	go.opentelemetry.io/collector/processor v1.36.1 // indirect
-	go.opentelemetry.io/collector/processor/batchprocessor v0.130.1 // indirect
-	go.opentelemetry.io/collector/processor/memorylimiterprocessor v0.130.1 // indirect
+	go.opentelemetry.io/collector/processor v1.36.1
+	go.opentelemetry.io/collector/processor/batchprocessor v0.130.1
+	go.opentelemetry.io/collector/processor/memorylimiterprocessor v0.130.1
 	go.opentelemetry.io/collector/processor/processorhelper v0.130.1 // indirect
 	go.opentelemetry.io/collector/processor/processorhelper/xprocessorhelper v0.130.1 // indirect
 	go.opentelemetry.io/collector/processor/processortest v0.130.1 // indirect
 	go.opentelemetry.io/collector/processor/xprocessor v0.130.1 // indirect
-	go.opentelemetry.io/collector/receiver v1.36.1 // indirect
-	go.opentelemetry.io/collector/receiver/otlpreceiver v0.130.1 // indirect
+	go.opentelemetry.io/collector/receiver v1.36.1
+	go.opentelemetry.io/collector/receiver/otlpreceiver v0.130.1
 	go.opentelemetry.io/collector/receiver/receiverhelper v0.130.1 // indirect
 	go.opentelemetry.io/collector/receiver/receivertest v0.130.1 // indirect
 	go.opentelemetry.io/collector/receiver/xreceiver v0.130.1 // indirect
@@ -247,7 +247,7 @@ require (
 	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.62.0 // indirect
 	go.opentelemetry.io/contrib/otelconf v0.17.0 // indirect
 	go.opentelemetry.io/contrib/propagators/b3 v1.37.0 // indirect
-	go.opentelemetry.io/otel v1.37.0 // indirect
+	go.opentelemetry.io/otel v1.37.0
 	go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc v0.13.0 // indirect
 	go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp v0.13.0 // indirect
 	go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc v1.37.0 // indirect
@@ -260,10 +260,10 @@ require (
 	go.opentelemetry.io/otel/exporters/stdout/stdoutmetric v1.37.0 // indirect
 	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.37.0 // indirect
 	go.opentelemetry.io/otel/log v0.13.0 // indirect
-	go.opentelemetry.io/otel/metric v1.37.0 // indirect
+	go.opentelemetry.io/otel/metric v1.37.0
 	go.opentelemetry.io/otel/sdk v1.37.0 // indirect
 	go.opentelemetry.io/otel/sdk/log v0.13.0 // indirect
-	go.opentelemetry.io/otel/sdk/metric v1.37.0 // indirect
+	go.opentelemetry.io/otel/sdk/metric v1.37.0
 	go.opentelemetry.io/otel/trace v1.37.0 // indirect
 	go.opentelemetry.io/proto/otlp v1.7.0 // indirect
 	go.yaml.in/yaml/v3 v3.0.4 // indirect
@@ -281,7 +281,7 @@ require (
 	google.golang.org/grpc v1.74.2 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/apimachinery v0.33.3 // indirect
 	k8s.io/client-go v0.33.3 // indirect
 	k8s.io/klog/v2 v2.130.1 // indirect
@@ -16,8 +16,13 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/confmap/provider/s3provider"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/confmap/provider/secretsmanagerprovider"
@@ -28,11 +33,30 @@ import (
 	"go.opentelemetry.io/collector/confmap/provider/httpprovider"
 	"go.opentelemetry.io/collector/confmap/provider/httpsprovider"
 	"go.opentelemetry.io/collector/confmap/provider/yamlprovider"
+	"go.opentelemetry.io/collector/extension"
 	"go.opentelemetry.io/collector/otelcol"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
 
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter"
 	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/disablequeuedretryconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/lintconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/provider/lambdaprovider"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/provider/logzioprovider"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/provider/ssmprovider"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/extensionapi"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/queuedrain"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+var (
+	// ErrInvalidConfig is wrapped by the errors returned by Start when the configuration cannot be resolved or is
+	// invalid.
+	ErrInvalidConfig = errors.New("invalid collector configuration")
+	// ErrStartFailed is wrapped by the errors returned by Start when a valid configuration fails to start, for example
+	// because a component cannot bind its port.
+	ErrStartFailed = errors.New("collector failed to start")
 )
 
 // Collector runs a single otelcol as a go routine within the
@@ -40,18 +64,44 @@ import (
 type Collector struct {
 	factories otelcol.Factories
 	cfgProSet otelcol.ConfigProviderSettings
+	// mu guards the running collector, which is replaced when the collector is restarted while it may be stopped or
+	// watched concurrently.
+	mu        sync.Mutex
 	svc       *otelcol.Collector
 	appDone   chan struct{}
+	runErr    error
 	stopped   bool
-	logger    *zap.Logger
-	version   string
+	readiness *readiness
+	// conf is the resolved configuration the collector runs.
+	conf *confmap.Conf
+	// fallback enables the built-in configuration, and fallbackErr is the error of the configuration it replaces. It is
+	// guarded by mu, as it is reported by the collector's own telemetry.
+	fallback    bool
+	fallbackErr error
+	// printConfig logs the configuration whenever the collector starts running a new one.
+	printConfig bool
+	// secrets are redacted from the printed configuration.
+	secrets *secrets
+	// restarts returns the number of times the collector was restarted by its supervisor.
+	restarts func() int
+	// degraded is the reason the extension cannot use the Telemetry API, if it cannot.
+	degraded extensionapi.ErrorType
+	// drainer tracks the sending queues of the exporters when they are drained, and is nil otherwise.
+	drainer *queuedrain.Drainer
+	logger  *zap.Logger
+	version string
 }
 
-func getConfig(logger *zap.Logger) string {
+// configURISeparator separates the URIs of OPENTELEMETRY_COLLECTOR_CONFIG_URI. Commas are not used, as they are
+// common in inline YAML configurations.
+const configURISeparator = ";"
+
+// getConfigURIs returns the URIs of the configuration, in the order they are merged.
+func getConfigURIs(logger *zap.Logger) []string {
 	val, ex := os.LookupEnv("OPENTELEMETRY_COLLECTOR_CONFIG_URI")
 	if ex {
 		logger.Info("Using config URI from environment variable", zap.String("uri", val))
-		return val
+		return splitConfigURIs(val)
 	}
 
 	// The name of the environment variable was changed
@@ -60,46 +110,281 @@ func getConfig(logger *zap.Logger) string {
 	if oldEx {
 		logger.Info("Using config URI from deprecated environment variable", zap.String("uri", oldVal))
 		logger.Warn("The OPENTELEMETRY_COLLECTOR_CONFIG_FILE environment variable is deprecated. Please use OPENTELEMETRY_COLLECTOR_CONFIG_URI instead.")
-		return oldVal
+		return splitConfigURIs(oldVal)
+	}
+
+	// Without a configuration, one is generated when a Logz.io token is set.
+	if logzioprovider.Enabled() {
+		logger.Info("Using the configuration generated from the Logz.io environment variables", zap.String("uri", logzioprovider.URI))
+		return []string{logzioprovider.URI}
 	}
 
 	// If neither environment variable is set, use the default
 	defaultVal := "/opt/collector-config/config.yaml"
 	logger.Info("Using default config URI", zap.String("uri", defaultVal))
-	return defaultVal
+	return []string{defaultVal}
+}
+
+// splitConfigURIs splits a list of URIs separated by semicolons. Empty entries are ignored.
+func splitConfigURIs(val string) []string {
+	var uris []string
+	for _, uri := range strings.Split(val, configURISeparator) {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// PrintEffectiveConfig returns whether the configuration the collector runs is logged once it is resolved, as enabled
+// by the OPENTELEMETRY_EXTENSION_PRINT_EFFECTIVE_CONFIG environment variable.
+func PrintEffectiveConfig(logger *zap.Logger) bool {
+	val, ex := os.LookupEnv("OPENTELEMETRY_EXTENSION_PRINT_EFFECTIVE_CONFIG")
+	if !ex {
+		return false
+	}
+	enabled, err := strconv.ParseBool(val)
+	if err != nil {
+		logger.Warn("Invalid print effective config, the configuration is not printed", zap.String("print", val))
+		return false
+	}
+	return enabled
+}
+
+// ExporterQueueMode returns how the sending queue of exporters is handled, as selected by the
+// OPENTELEMETRY_EXTENSION_EXPORTER_QUEUE_MODE environment variable.
+func ExporterQueueMode(logger *zap.Logger) disablequeuedretryconverter.Mode {
+	mode, err := disablequeuedretryconverter.ParseMode(os.Getenv("OPENTELEMETRY_EXTENSION_EXPORTER_QUEUE_MODE"))
+	if err != nil {
+		logger.Warn("Invalid exporter queue mode, falling back to the default", zap.Error(err), zap.String("mode", string(mode)))
+	}
+	return mode
+}
+
+// ConfigLintMode returns whether the configuration issues found by the lint auto-configuration step are logged or fail
+// the configuration, as selected by the OPENTELEMETRY_EXTENSION_CONFIG_LINT_MODE environment variable.
+func ConfigLintMode(logger *zap.Logger) lintconverter.Mode {
+	mode, err := lintconverter.ParseMode(os.Getenv("OPENTELEMETRY_EXTENSION_CONFIG_LINT_MODE"))
+	if err != nil {
+		logger.Warn("Invalid config lint mode, falling back to the default", zap.Error(err), zap.String("mode", string(mode)))
+	}
+	return mode
+}
+
+// ExporterQueueDrainTimeout returns how long the lifecycle is blocked while draining the sending queue of exporters,
+// as set by the OPENTELEMETRY_EXTENSION_EXPORTER_QUEUE_DRAIN_TIMEOUT environment variable.
+func ExporterQueueDrainTimeout(logger *zap.Logger) time.Duration {
+	defaultVal := 2 * time.Second
+	val, ex := os.LookupEnv("OPENTELEMETRY_EXTENSION_EXPORTER_QUEUE_DRAIN_TIMEOUT")
+	if !ex {
+		return defaultVal
+	}
+	timeout, err := time.ParseDuration(val)
+	if err != nil || timeout <= 0 {
+		logger.Warn("Invalid exporter queue drain timeout, falling back to the default", zap.String("timeout", val), zap.Duration("default", defaultVal))
+		return defaultVal
+	}
+	return timeout
+}
+
+// RestartPolicy selects what happens when the collector stops while the environment is running.
+type RestartPolicy string
+
+const (
+	// RestartPolicyRestart restarts the collector with the same configuration, up to a maximum number of times.
+	RestartPolicyRestart RestartPolicy = "restart"
+	// RestartPolicyExit reports the crash to Lambda and exits the extension.
+	RestartPolicyExit RestartPolicy = "exit"
+)
+
+// CollectorRestartPolicy returns what happens when the collector stops unexpectedly, as selected by the
+// OPENTELEMETRY_EXTENSION_COLLECTOR_RESTART_POLICY environment variable.
+func CollectorRestartPolicy(logger *zap.Logger) RestartPolicy {
+	val, ex := os.LookupEnv("OPENTELEMETRY_EXTENSION_COLLECTOR_RESTART_POLICY")
+	if !ex {
+		return RestartPolicyRestart
+	}
+	switch policy := RestartPolicy(strings.ToLower(strings.TrimSpace(val))); policy {
+	case RestartPolicyRestart, RestartPolicyExit:
+		return policy
+	default:
+		logger.Warn("Invalid collector restart policy, falling back to the default", zap.String("policy", val), zap.String("default", string(RestartPolicyRestart)))
+		return RestartPolicyRestart
+	}
+}
+
+// CollectorMaxRestarts returns how many times the collector is restarted before the extension exits, as set by the
+// OPENTELEMETRY_EXTENSION_COLLECTOR_MAX_RESTARTS environment variable.
+func CollectorMaxRestarts(logger *zap.Logger) int {
+	defaultVal := 3
+	val, ex := os.LookupEnv("OPENTELEMETRY_EXTENSION_COLLECTOR_MAX_RESTARTS")
+	if !ex {
+		return defaultVal
+	}
+	restarts, err := strconv.Atoi(val)
+	if err != nil || restarts < 0 {
+		logger.Warn("Invalid collector max restarts, falling back to the default", zap.String("restarts", val), zap.Int("default", defaultVal))
+		return defaultVal
+	}
+	return restarts
+}
+
+// accountID returns the AWS account ID of the function: the one set in AWS_ACCOUNT_ID, or else the one returned when
+// the extension registered, as the lambda provider does.
+func accountID() string {
+	if id := os.Getenv("AWS_ACCOUNT_ID"); id != "" {
+		return id
+	}
+	return lambdalifecycle.AccountID()
 }
 
 func NewCollector(logger *zap.Logger, factories otelcol.Factories, version string) *Collector {
 	l := logger.Named("NewCollector")
-	cfgSet := otelcol.ConfigProviderSettings{
+	queueMode := ExporterQueueMode(l)
+	secrets := newSecrets()
+	col := &Collector{
+		secrets:     secrets,
+		fallback:    ConfigFallback(l),
+		printConfig: PrintEffectiveConfig(l),
+		logger:      logger,
+		version:     version,
+	}
+	if queueMode == disablequeuedretryconverter.ModeDrain {
+		col.drainer = queuedrain.New(logger, ExporterQueueDrainTimeout(l))
+		factories.Exporters = col.drainer.WrapExporters(factories.Exporters)
+	}
+	col.cfgProSet = otelcol.ConfigProviderSettings{
 		ResolverSettings: confmap.ResolverSettings{
-			URIs:              []string{getConfig(l)},
-			ProviderFactories: []confmap.ProviderFactory{fileprovider.NewFactory(), envprovider.NewFactory(), yamlprovider.NewFactory(), httpsprovider.NewFactory(), httpprovider.NewFactory(), s3provider.NewFactory(), secretsmanagerprovider.NewFactory()},
-			ConverterFactories: []confmap.ConverterFactory{
-				confmap.NewConverterFactory(func(set confmap.ConverterSettings) confmap.Converter {
-					return disablequeuedretryconverter.New()
-				}),
-			},
+			URIs:              getConfigURIs(l),
+			ProviderFactories: []confmap.ProviderFactory{fileprovider.NewFactory(), secrets.wrap(envprovider.NewFactory()), yamlprovider.NewFactory(), httpsprovider.NewFactory(), httpprovider.NewFactory(), s3provider.NewFactory(), secrets.wrap(secretsmanagerprovider.NewFactory()), secrets.wrap(ssmprovider.NewFactory()), lambdaprovider.NewFactory(version), logzioprovider.NewFactory()},
+			ConverterFactories: converter.Default(converter.Options{
+				QueueMode:          queueMode,
+				Factories:          factories,
+				FunctionMemoryMB:   FunctionMemoryMB(l),
+				ExtensionMemoryMiB: ExtensionMemoryMiB(l),
+				AccountID:          accountID,
+				Degraded:           func() bool { return col.degraded != "" },
+				LintMode:           ConfigLintMode(l),
+			}).Factories(l),
+			ConverterSettings: confmap.ConverterSettings{Logger: l},
 		},
 	}
 
-	col := &Collector{
-		factories: factories,
-		cfgProSet: cfgSet,
-		logger:    logger,
-		version:   version,
-	}
+	col.factories = withReadiness(factories, func() *readiness { return col.readiness }, func() int {
+		if col.restarts == nil {
+			return 0
+		}
+		return col.restarts()
+	}, col.degradedReasons)
 	return col
 }
 
+// QueueDrainer returns the drainer of the sending queues of the exporters, or nil if the exporter queue mode is not
+// drain.
+func (c *Collector) QueueDrainer() *queuedrain.Drainer {
+	return c.drainer
+}
+
+// ReportRestarts sets the number of restarts of the collector, as counted by its supervisor, that the collector reports
+// in its own telemetry. It must be called before the collector is started.
+func (c *Collector) ReportRestarts(restarts func() int) {
+	c.restarts = restarts
+}
+
+// RunDegraded runs the collector in the degraded mode of the extension, which cannot use the Telemetry API for reason,
+// such as extensionapi.SubscribeFailed. The telemetryapireceiver is removed from the configuration, so that the
+// collector only receives OTLP, and the reason is reported by the lambda_extension_degraded metric of the collector's
+// own telemetry. It must be called before the collector is started.
+func (c *Collector) RunDegraded(reason extensionapi.ErrorType) {
+	c.degraded = reason
+}
+
+// degradedReasons returns the reasons the extension runs in a degraded mode: the Telemetry API is unavailable, or the
+// collector fell back to its built-in configuration.
+func (c *Collector) degradedReasons() []string {
+	var reasons []string
+	if c.degraded != "" {
+		reasons = append(reasons, string(c.degraded))
+	}
+	if c.ConfigFallbackError() != nil {
+		reasons = append(reasons, string(extensionapi.ConfigInvalid))
+	}
+	return reasons
+}
+
+// withReadiness returns a copy of factories with the factory of the readiness extension.
+func withReadiness(factories otelcol.Factories, current func() *readiness, restarts func() int, degraded func() []string) otelcol.Factories {
+	extensions := make(map[component.Type]extension.Factory, len(factories.Extensions)+1)
+	for t, f := range factories.Extensions {
+		extensions[t] = f
+	}
+	extensions[readinessType] = newReadinessFactory(current, restarts, degraded)
+	factories.Extensions = extensions
+	return factories
+}
+
+// Start starts the collector. The configuration is resolved and validated on the first start only: later starts, such
+// as restarts after a crash, run the same configuration. If the configuration is invalid and the fallback is enabled,
+// the collector starts with the built-in configuration instead.
 func (c *Collector) Start(ctx context.Context) error {
-	params := otelcol.CollectorSettings{
+	if c.conf != nil {
+		return c.start(ctx, c.conf)
+	}
+	conf, err := c.resolveConfig(ctx)
+	if err == nil {
+		err = c.validate(ctx, conf)
+	}
+	if err == nil {
+		err = c.start(ctx, conf)
+	}
+	if err == nil {
+		c.setConf(conf)
+		return nil
+	}
+	if c.fallback && errors.Is(err, ErrInvalidConfig) {
+		return c.startFallback(ctx, err)
+	}
+	return err
+}
+
+// setConf records the configuration the collector runs.
+func (c *Collector) setConf(conf *confmap.Conf) {
+	c.conf = conf
+	if !c.printConfig {
+		return
+	}
+	effective, err := c.EffectiveConfig()
+	if err != nil {
+		c.logger.Warn("Failed to print the effective configuration", zap.Error(err))
+		return
+	}
+	c.logger.Info("Effective configuration\n" + string(effective))
+}
+
+// EffectiveConfig returns the configuration the collector runs as YAML, after the URIs were merged and the converters
+// applied. The secrets referenced by the configuration are redacted.
+func (c *Collector) EffectiveConfig() ([]byte, error) {
+	if c.conf == nil {
+		return nil, errors.New("the collector has not started")
+	}
+	return c.marshalRedacted(c.conf)
+}
+
+// marshalRedacted returns conf as YAML, with the values of secret keys and the values retrieved by the providers of
+// secrets redacted.
+func (c *Collector) marshalRedacted(conf *confmap.Conf) ([]byte, error) {
+	return yaml.Marshal(c.secrets.redact(conf.ToStringMap()))
+}
+
+// settings returns the settings of a collector running the resolved configuration conf.
+func (c *Collector) settings(conf *confmap.Conf) otelcol.CollectorSettings {
+	return otelcol.CollectorSettings{
 		BuildInfo: component.BuildInfo{
 			Command:     "otelcol-lambda",
 			Description: "Lambda Collector",
 			Version:     c.version,
 		},
-		ConfigProviderSettings: c.cfgProSet,
+		ConfigProviderSettings: resolvedConfigSettings(conf),
 		Factories: func() (otelcol.Factories, error) {
 			return c.factories, nil
 		},
@@ -107,47 +392,90 @@ func (c *Collector) Start(ctx context.Context) error {
 			return c.logger.Core()
 		})},
 	}
-	var err error
-	c.svc, err = otelcol.NewCollector(params)
+}
+
+// start starts the collector with the resolved configuration conf, which has been validated. The errors returned wrap
+// ErrStartFailed.
+func (c *Collector) start(ctx context.Context, conf *confmap.Conf) error {
+	if c.drainer != nil {
+		// The exporters of the previous collector, if any, are shut down.
+		c.drainer.Reset(ctx)
+	}
+	svc, err := otelcol.NewCollector(c.settings(conf))
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrStartFailed, err)
 	}
+	c.readiness = newReadiness(c.logger)
+	ready := c.readiness.ready
+	appDone := make(chan struct{})
 
-	c.appDone = make(chan struct{})
+	c.mu.Lock()
+	c.svc = svc
+	c.runErr = nil
+	c.stopped = false
+	c.appDone = appDone
+	c.mu.Unlock()
 
+	var runErr error
 	go func() {
-		defer close(c.appDone)
-		appErr := c.svc.Run(ctx)
-		if appErr != nil {
-			err = appErr
+		// runErr is set before appDone is closed, so that it is visible to whoever waits on Done.
+		defer close(appDone)
+		runErr = svc.Run(ctx)
+		c.mu.Lock()
+		if c.svc == svc {
+			c.runErr = runErr
 		}
+		c.mu.Unlock()
 	}()
 
-	for {
-		state := c.svc.GetState()
-
-		// While waiting for collector start, an error was found. Most likely
-		// an invalid custom collector configuration file.
-		if err != nil {
-			return err
+	select {
+	case <-ready:
+		return nil
+	case <-appDone:
+		// The collector stopped before all pipelines were started, such as when a component fails to start.
+		if runErr != nil {
+			return fmt.Errorf("%w: %w", ErrStartFailed, runErr)
 		}
+		return fmt.Errorf("%w: otelcol state is %s", ErrStartFailed, svc.GetState().String())
+	}
+}
 
-		switch state {
-		case otelcol.StateStarting:
-			// NoOp
-		case otelcol.StateRunning:
-			return nil
-		default:
-			err = fmt.Errorf("unable to start, otelcol state is %s", state.String())
-		}
+// ComponentStartups returns how long each component of the running collector took to start, in the order they
+// started.
+func (c *Collector) ComponentStartups() []lambdalifecycle.StartupPhase {
+	if c.readiness == nil {
+		return nil
 	}
+	return c.readiness.componentStartups()
 }
 
-func (c *Collector) Stop() error {
+// Done returns a channel that is closed when the collector stops running, whether it was stopped or not.
+func (c *Collector) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.appDone
+}
+
+// Err returns the error the collector stopped running with. It is only set once Done is closed.
+func (c *Collector) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runErr
+}
+
+// Stop shuts the collector down and waits for it to finish, or for ctx to be done.
+func (c *Collector) Stop(ctx context.Context) error {
+	c.mu.Lock()
 	if !c.stopped {
 		c.stopped = true
 		c.svc.Shutdown()
 	}
-	<-c.appDone
-	return nil
+	appDone := c.appDone
+	c.mu.Unlock()
+	select {
+	case <-appDone:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("collector did not stop in time: %w", ctx.Err())
+	}
 }
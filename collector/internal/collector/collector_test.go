@@ -0,0 +1,459 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/debugexporter"
+	"go.opentelemetry.io/collector/exporter/otlphttpexporter"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"go.opentelemetry.io/collector/otelcol"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/batchprocessor"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/otlpreceiver"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap/zaptest"
+	"gopkg.in/yaml.v3"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/extensionapi"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor"
+)
+
+// nopNotifier lets components that register as lifecycle listeners, such as the decouple processor, be created.
+type nopNotifier struct{}
+
+func (nopNotifier) AddListener(lambdalifecycle.Listener) {}
+
+func TestMain(m *testing.M) {
+	lambdalifecycle.SetNotifier(nopNotifier{})
+	os.Exit(m.Run())
+}
+
+func testFactories(t *testing.T) otelcol.Factories {
+	receivers, err := otelcol.MakeFactoryMap[receiver.Factory](otlpreceiver.NewFactory())
+	require.NoError(t, err)
+	processors, err := otelcol.MakeFactoryMap[processor.Factory](batchprocessor.NewFactory(), decoupleprocessor.NewFactory())
+	require.NoError(t, err)
+	exporters, err := otelcol.MakeFactoryMap[exporter.Factory](debugexporter.NewFactory())
+	require.NoError(t, err)
+	return otelcol.Factories{Receivers: receivers, Processors: processors, Exporters: exporters}
+}
+
+func writeConfig(t *testing.T, config string) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+	t.Setenv("OPENTELEMETRY_COLLECTOR_CONFIG_URI", path)
+}
+
+func TestStart(t *testing.T) {
+	writeConfig(t, `
+receivers:
+  otlp:
+    protocols:
+      http:
+        endpoint: localhost:0
+processors:
+  batch:
+exporters:
+  debug:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [debug]
+`)
+	c := NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	require.NoError(t, c.Start(context.Background()))
+
+	var names []string
+	for _, phase := range c.ComponentStartups() {
+		require.False(t, phase.End.Before(phase.Start))
+		names = append(names, phase.Name)
+	}
+	require.ElementsMatch(t, []string{"receiver/otlp", "processor/batch[traces]", "processor/decouple[traces]", "exporter/debug"}, names)
+	effective, err := c.EffectiveConfig()
+	require.NoError(t, err)
+	require.NotContains(t, string(effective), "lambda_readiness")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, c.Stop(ctx))
+	<-c.Done()
+	require.NoError(t, c.Err())
+}
+
+func TestStartInvalidConfig(t *testing.T) {
+	writeConfig(t, `
+receivers:
+  otlp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [debug]
+`)
+	c := NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	err := c.Start(context.Background())
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.NotErrorIs(t, err, ErrStartFailed)
+	// The configuration is validated before the collector runs.
+	require.Nil(t, c.Done())
+}
+
+func TestStartFailed(t *testing.T) {
+	// The port of the receiver is taken, so that the valid configuration fails to start.
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer l.Close()
+	writeConfig(t, fmt.Sprintf(`
+receivers:
+  otlp:
+    protocols:
+      http:
+        endpoint: %s
+exporters:
+  debug:
+service:
+  # The telemetry of a collector failing to start is not shut down, so it does not bind its port.
+  telemetry:
+    metrics:
+      level: none
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [debug]
+`, l.Addr()))
+	c := NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	err = c.Start(context.Background())
+	require.ErrorIs(t, err, ErrStartFailed)
+	require.NotErrorIs(t, err, ErrInvalidConfig)
+	<-c.Done()
+}
+
+func TestReload(t *testing.T) {
+	config := `
+receivers:
+  otlp:
+    protocols:
+      http:
+        endpoint: localhost:0
+exporters:
+  debug:
+    verbosity: %s
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [debug]
+`
+	writeConfig(t, fmt.Sprintf(config, "basic"))
+	path := os.Getenv("OPENTELEMETRY_COLLECTOR_CONFIG_URI")
+	c := NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	require.NoError(t, c.Start(context.Background()))
+	defer func() {
+		require.NoError(t, c.Stop(context.Background()))
+	}()
+
+	restarted, err := c.Reload(context.Background())
+	require.NoError(t, err)
+	require.False(t, restarted)
+
+	// An invalid configuration is not applied, the collector keeps running.
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(config, "loud")), 0o600))
+	done := c.Done()
+	restarted, err = c.Reload(context.Background())
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.False(t, restarted)
+	require.Equal(t, done, c.Done())
+
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(config, "detailed")), 0o600))
+	restarted, err = c.Reload(context.Background())
+	require.NoError(t, err)
+	require.True(t, restarted)
+	require.NotEqual(t, done, c.Done())
+	require.Equal(t, "detailed", c.conf.Get("exporters::debug::verbosity"))
+}
+
+func TestResolvedConfigSettings(t *testing.T) {
+	conf := confmap.NewFromStringMap(map[string]any{
+		"exporters": map[string]any{"debug": map[string]any{"headers": []any{"$token", "${env:TOKEN}"}}},
+	})
+	resolver, err := confmap.NewResolver(resolvedConfigSettings(conf).ResolverSettings)
+	require.NoError(t, err)
+	resolved, err := resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, conf.Get("exporters"), resolved.Get("exporters"))
+	require.Equal(t, []any{"lambda_readiness"}, resolved.Get("service::extensions"), "the readiness extension is added to the running configuration")
+}
+
+func TestStartFallback(t *testing.T) {
+	writeConfig(t, `
+receivers:
+  otlp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [debug]
+`)
+	c := NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	require.ErrorIs(t, c.Start(context.Background()), ErrInvalidConfig)
+	require.Nil(t, c.Done())
+
+	t.Setenv("OPENTELEMETRY_EXTENSION_CONFIG_FALLBACK", "true")
+	c = NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	require.NoError(t, c.Start(context.Background()))
+	defer func() {
+		require.NoError(t, c.Stop(context.Background()))
+	}()
+	require.ErrorIs(t, c.ConfigFallbackError(), ErrInvalidConfig)
+	require.Equal(t, []any{"otlp"}, c.conf.Get("service::pipelines::logs::receivers"))
+	require.Equal(t, []any{"debug"}, c.conf.Get("service::pipelines::logs::exporters"))
+}
+
+func TestFallbackConfig(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://otlp.example.com")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret, x-team = lambda")
+	factories := testFactories(t)
+	factories.Exporters[otlpHTTPExporterType] = otlphttpexporter.NewFactory()
+	c := NewCollector(zaptest.NewLogger(t), factories, "test")
+
+	conf, err := c.fallbackConfig()
+	require.NoError(t, err)
+	require.Equal(t, "https://otlp.example.com", conf.Get("exporters::otlphttp::endpoint"))
+	require.Equal(t, map[string]any{"api-key": "secret", "x-team": "lambda"}, conf.Get("exporters::otlphttp::headers"))
+	for _, signal := range []string{"traces", "metrics", "logs"} {
+		require.Equal(t, []any{"otlphttp"}, conf.Get("service::pipelines::"+signal+"::exporters"))
+	}
+}
+
+func TestSplitConfigURIs(t *testing.T) {
+	require.Equal(t, []string{"/opt/collector-config/config.yaml"}, splitConfigURIs("/opt/collector-config/config.yaml"))
+	require.Equal(t,
+		[]string{"/opt/collector-config/config.yaml", "s3://bucket.s3.eu-west-1.amazonaws.com/team.yaml", "/var/task/collector.yaml"},
+		splitConfigURIs(" /opt/collector-config/config.yaml ; s3://bucket.s3.eu-west-1.amazonaws.com/team.yaml;;/var/task/collector.yaml "),
+	)
+	require.Equal(t, []string{"yaml:exporters::debug::sampling: {initial: 1, thereafter: 10}"}, splitConfigURIs("yaml:exporters::debug::sampling: {initial: 1, thereafter: 10}"))
+}
+
+func TestLayeredConfig(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	require.NoError(t, os.WriteFile(base, []byte(`
+receivers:
+  otlp:
+    protocols:
+      http:
+        endpoint: localhost:0
+processors:
+  batch:
+exporters:
+  debug:
+    verbosity: basic
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [debug]
+`), 0o600))
+	override := filepath.Join(dir, "override.yaml")
+	require.NoError(t, os.WriteFile(override, []byte(`
+exporters:
+  debug:
+    verbosity: detailed
+service:
+  pipelines:
+    traces:
+      processors: []
+`), 0o600))
+	t.Setenv("OPENTELEMETRY_COLLECTOR_CONFIG_URI", base+";"+override)
+	t.Setenv("OPENTELEMETRY_EXTENSION_PRINT_EFFECTIVE_CONFIG", "true")
+
+	c := NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	require.NoError(t, c.Start(context.Background()))
+	defer func() {
+		require.NoError(t, c.Stop(context.Background()))
+	}()
+
+	// Maps are merged and later scalars win, lists are replaced as a whole.
+	require.Equal(t, "detailed", c.conf.Get("exporters::debug::verbosity"))
+	require.Equal(t, []any{"otlp"}, c.conf.Get("service::pipelines::traces::receivers"))
+	require.Equal(t, []any{}, c.conf.Get("service::pipelines::traces::processors"))
+
+	effective, err := c.EffectiveConfig()
+	require.NoError(t, err)
+	require.Contains(t, string(effective), "verbosity: detailed")
+}
+
+func TestEffectiveConfigRedactsSecrets(t *testing.T) {
+	t.Setenv("TEST_OTLP_ENDPOINT", "localhost:4318")
+	t.Setenv("TEST_TOKEN", "secret-token")
+	writeConfig(t, `
+receivers:
+  otlp:
+    protocols:
+      http:
+        endpoint: ${env:TEST_OTLP_ENDPOINT}
+exporters:
+  debug:
+  otlphttp:
+    endpoint: https://backend.example.com
+    headers:
+      x-api-key: ${env:TEST_TOKEN}
+  logzio:
+    account_token: plain-token
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [debug]
+`)
+	effective, err := NewCollector(zaptest.NewLogger(t), testFactories(t), "test").ResolveEffectiveConfig(context.Background())
+	require.NoError(t, err)
+
+	var conf map[string]any
+	require.NoError(t, yaml.Unmarshal(effective, &conf))
+	c := confmap.NewFromStringMap(conf)
+	require.Equal(t, redacted, c.Get("receivers::otlp::protocols::http::endpoint"), "retrieved by the env provider")
+	require.Equal(t, map[string]any{"x-api-key": redacted}, c.Get("exporters::otlphttp::headers"))
+	require.Equal(t, redacted, c.Get("exporters::logzio::account_token"))
+	require.Equal(t, "https://backend.example.com", c.Get("exporters::otlphttp::endpoint"))
+	require.NotContains(t, string(effective), "secret-token")
+	require.NotContains(t, string(effective), "plain-token")
+}
+
+func TestExtensionMemoryMiB(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	require.Zero(t, ExtensionMemoryMiB(logger), "unknown outside of Lambda")
+
+	t.Setenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE", "1024")
+	require.Equal(t, 256, ExtensionMemoryMiB(logger))
+
+	t.Setenv("OPENTELEMETRY_EXTENSION_MEMORY_PERCENTAGE", "50")
+	require.Equal(t, 512, ExtensionMemoryMiB(logger))
+
+	t.Setenv("OPENTELEMETRY_EXTENSION_MEMORY_PERCENTAGE", "150")
+	require.Equal(t, 256, ExtensionMemoryMiB(logger))
+}
+
+func TestMemoryLimitMiB(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	require.Zero(t, memoryLimitMiB(logger), "unknown outside of Lambda")
+
+	t.Setenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE", "1024")
+	require.Equal(t, 256, memoryLimitMiB(logger))
+
+	t.Setenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE", "128")
+	require.Zero(t, memoryLimitMiB(logger), "too small for a limit")
+}
+
+func TestValidate(t *testing.T) {
+	config := `
+receivers:
+  otlp:
+    protocols:
+      http:
+exporters:
+  %s:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [debug]
+`
+	writeConfig(t, fmt.Sprintf(config, "debug"))
+	c := NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	require.NoError(t, c.Validate(context.Background()))
+	effective, err := c.ResolveEffectiveConfig(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, string(effective), "debug")
+	require.NotContains(t, string(effective), "lambda_readiness")
+
+	writeConfig(t, fmt.Sprintf(config, "unknown"))
+	c = NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	require.ErrorIs(t, c.Validate(context.Background()), ErrInvalidConfig)
+}
+
+func TestReadinessReportsRestarts(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	set := extensiontest.NewNopSettings(readinessType)
+	set.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	factory := newReadinessFactory(func() *readiness { return newReadiness(zaptest.NewLogger(t)) }, func() int { return 2 }, func() []string { return nil })
+	_, err := factory.Create(context.Background(), set, factory.CreateDefaultConfig())
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	m := rm.ScopeMetrics[0].Metrics[0]
+	require.Equal(t, "lambda_collector_restarts", m.Name)
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.True(t, sum.IsMonotonic)
+	require.Len(t, sum.DataPoints, 1)
+	require.Equal(t, int64(2), sum.DataPoints[0].Value)
+}
+
+func TestReadinessReportsDegraded(t *testing.T) {
+	c := NewCollector(zaptest.NewLogger(t), testFactories(t), "test")
+	reader := sdkmetric.NewManualReader()
+	set := extensiontest.NewNopSettings(readinessType)
+	set.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	factory := newReadinessFactory(func() *readiness { return newReadiness(zaptest.NewLogger(t)) }, func() int { return 0 }, c.degradedReasons)
+	_, err := factory.Create(context.Background(), set, factory.CreateDefaultConfig())
+	require.NoError(t, err)
+
+	degraded := func() map[string]int64 {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		reasons := map[string]int64{}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name != "lambda_extension_degraded" {
+					continue
+				}
+				gauge, ok := m.Data.(metricdata.Gauge[int64])
+				require.True(t, ok)
+				for _, dp := range gauge.DataPoints {
+					reason, _ := dp.Attributes.Value("reason")
+					reasons[reason.AsString()] = dp.Value
+				}
+			}
+		}
+		return reasons
+	}
+	require.Empty(t, degraded())
+
+	c.RunDegraded(extensionapi.SubscribeFailed)
+	c.setConfigFallbackError(errors.New("invalid"))
+	require.Equal(t, map[string]int64{"Extension.SubscribeFailed": 1, "Extension.ConfigInvalid": 1}, degraded())
+}
@@ -0,0 +1,152 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+//go:embed fallback.yaml
+var fallbackConfig []byte
+
+var (
+	otlpHTTPExporterType     = component.MustNewType("otlphttp")
+	telemetryAPIReceiverType = component.MustNewType("telemetryapireceiver")
+)
+
+// ConfigFallback returns whether the collector starts with a built-in configuration when the configuration cannot be
+// resolved or is invalid, as enabled by the OPENTELEMETRY_EXTENSION_CONFIG_FALLBACK environment variable.
+func ConfigFallback(logger *zap.Logger) bool {
+	val, ex := os.LookupEnv("OPENTELEMETRY_EXTENSION_CONFIG_FALLBACK")
+	if !ex {
+		return false
+	}
+	enabled, err := strconv.ParseBool(val)
+	if err != nil {
+		logger.Warn("Invalid config fallback, the built-in configuration is not used", zap.String("fallback", val))
+		return false
+	}
+	return enabled
+}
+
+// ConfigFallbackError returns the error of the configuration the collector fell back from, or nil if it runs the
+// configuration at OPENTELEMETRY_COLLECTOR_CONFIG_URI.
+func (c *Collector) ConfigFallbackError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fallbackErr
+}
+
+func (c *Collector) setConfigFallbackError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fallbackErr = err
+}
+
+// startFallback starts the collector with the built-in configuration, after the configuration failed with configErr.
+func (c *Collector) startFallback(ctx context.Context, configErr error) error {
+	c.logger.Error("!!! The collector configuration is invalid, starting with the built-in fallback configuration. "+
+		"Telemetry is not processed as configured until the configuration is fixed. !!!",
+		zap.Error(configErr), zap.String("uri", strings.Join(c.cfgProSet.ResolverSettings.URIs, ",")))
+
+	fallback, err := c.fallbackConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load the fallback configuration: %w, after: %w", err, configErr)
+	}
+	settings := resolvedConfigSettings(fallback).ResolverSettings
+	settings.ConverterFactories = c.cfgProSet.ResolverSettings.ConverterFactories
+	settings.ConverterSettings = c.cfgProSet.ResolverSettings.ConverterSettings
+	conf, err := c.resolve(ctx, settings)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the fallback configuration: %w, after: %w", err, configErr)
+	}
+	if err = c.start(ctx, conf); err != nil {
+		return fmt.Errorf("failed to start with the fallback configuration: %w, after: %w", err, configErr)
+	}
+	c.setConf(conf)
+	c.setConfigFallbackError(configErr)
+	return nil
+}
+
+// fallbackConfig returns the built-in configuration. Data is exported with OTLP over HTTP when
+// OTEL_EXPORTER_OTLP_ENDPOINT is set, and logged by the debug exporter otherwise. The telemetryapi receiver is added
+// to the metrics pipeline when available, so that the fallback is reported.
+func (c *Collector) fallbackConfig() (*confmap.Conf, error) {
+	retrieved, err := confmap.NewRetrievedFromYAML(fallbackConfig)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := retrieved.AsConf()
+	if err != nil {
+		return nil, err
+	}
+
+	exporter := "debug"
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		if _, ok := c.factories.Exporters[otlpHTTPExporterType]; ok {
+			exporter = otlpHTTPExporterType.String()
+			otlp := map[string]any{"endpoint": endpoint}
+			if headers := parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")); len(headers) > 0 {
+				otlp["headers"] = headers
+			}
+			if err = conf.Merge(confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{exporter: otlp}})); err != nil {
+				return nil, err
+			}
+		} else {
+			c.logger.Warn("The otlphttp exporter is not available, the fallback configuration uses the debug exporter")
+		}
+	}
+
+	_, telemetryAPI := c.factories.Receivers[telemetryAPIReceiverType]
+	if telemetryAPI {
+		if err = conf.Merge(confmap.NewFromStringMap(map[string]any{"receivers": map[string]any{telemetryAPIReceiverType.String(): nil}})); err != nil {
+			return nil, err
+		}
+	}
+	pipelines := map[string]any{}
+	for _, signal := range []string{"traces", "metrics", "logs"} {
+		receivers := []any{"otlp"}
+		if telemetryAPI && signal == "metrics" {
+			receivers = append(receivers, telemetryAPIReceiverType.String())
+		}
+		pipelines[signal] = map[string]any{"receivers": receivers, "exporters": []any{exporter}}
+	}
+	if err = conf.Merge(confmap.NewFromStringMap(map[string]any{"service": map[string]any{"pipelines": pipelines}})); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// parseHeaders parses headers in the "key1=value1,key2=value2" form of OTEL_EXPORTER_OTLP_HEADERS.
+func parseHeaders(val string) map[string]any {
+	headers := map[string]any{}
+	for _, header := range strings.Split(val, ",") {
+		key, value, ok := strings.Cut(header, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"runtime/debug"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/memoryconverter"
+)
+
+// FunctionMemoryMB returns the memory of the function, as set by Lambda in the AWS_LAMBDA_FUNCTION_MEMORY_SIZE
+// environment variable, or zero if it is unknown.
+func FunctionMemoryMB(logger *zap.Logger) int {
+	val, ex := os.LookupEnv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE")
+	if !ex {
+		return 0
+	}
+	memory, err := strconv.Atoi(val)
+	if err != nil || memory <= 0 {
+		logger.Warn("Invalid function memory size", zap.String("memory", val))
+		return 0
+	}
+	return memory
+}
+
+// ExtensionMemoryPercentage returns the share of the function memory the extension is limited to, as set by the
+// OPENTELEMETRY_EXTENSION_MEMORY_PERCENTAGE environment variable.
+func ExtensionMemoryPercentage(logger *zap.Logger) int {
+	defaultVal := 25
+	val, ex := os.LookupEnv("OPENTELEMETRY_EXTENSION_MEMORY_PERCENTAGE")
+	if !ex {
+		return defaultVal
+	}
+	percentage, err := strconv.Atoi(val)
+	if err != nil || percentage <= 0 || percentage > 100 {
+		logger.Warn("Invalid extension memory percentage, falling back to the default", zap.String("percentage", val), zap.Int("default", defaultVal))
+		return defaultVal
+	}
+	return percentage
+}
+
+// ExtensionMemoryMiB returns the memory the extension is limited to, or zero if the memory of the function is
+// unknown.
+func ExtensionMemoryMiB(logger *zap.Logger) int {
+	return FunctionMemoryMB(logger) * ExtensionMemoryPercentage(logger) / 100
+}
+
+// SetMemoryLimit sets the soft memory limit of the Go runtime to the memory of the extension, so that the garbage
+// collector runs before the function runs out of memory. A GOMEMLIMIT set by the user is kept.
+func SetMemoryLimit(logger *zap.Logger) {
+	if val, ex := os.LookupEnv("GOMEMLIMIT"); ex {
+		logger.Debug("Using the memory limit from the environment", zap.String("limit", val))
+		return
+	}
+	limit := memoryLimitMiB(logger)
+	if limit <= 0 {
+		return
+	}
+	debug.SetMemoryLimit(int64(limit) << 20)
+	logger.Debug("Memory limit set", zap.Int("limit_mib", limit))
+}
+
+// memoryLimitMiB returns the soft memory limit of the Go runtime, or zero if it is not set because the memory of the
+// extension is unknown or too small for a limit to help.
+func memoryLimitMiB(logger *zap.Logger) int {
+	limit := ExtensionMemoryMiB(logger)
+	if limit > 0 && limit < memoryconverter.MinLimitMiB {
+		logger.Debug("Extension memory too small for a memory limit", zap.Int("memory_mib", limit), zap.Int("min_mib", memoryconverter.MinLimitMiB))
+		return 0
+	}
+	return limit
+}
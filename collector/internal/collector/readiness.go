@@ -0,0 +1,189 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/pipeline"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+// readinessType is the type of the extension added to every running configuration to tell when the collector has
+// started.
+var readinessType = component.MustNewType("lambda_readiness")
+
+// readinessScope is the instrumentation scope of the metrics of the readiness extension.
+const readinessScope = "github.com/open-telemetry/opentelemetry-lambda/collector/internal/collector"
+
+type readinessConfig struct{}
+
+// readiness is notified by the collector when all pipelines have started, and of the status of every component, so
+// that Start neither polls the collector state nor misses which component is slow to start.
+type readiness struct {
+	logger    *zap.Logger
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	mu         sync.Mutex
+	starting   map[*componentstatus.InstanceID]time.Time
+	components []lambdalifecycle.StartupPhase
+}
+
+func newReadiness(logger *zap.Logger) *readiness {
+	return &readiness{
+		logger:   logger,
+		ready:    make(chan struct{}),
+		starting: make(map[*componentstatus.InstanceID]time.Time),
+	}
+}
+
+func (r *readiness) Start(context.Context, component.Host) error {
+	return nil
+}
+
+func (r *readiness) Shutdown(context.Context) error {
+	return nil
+}
+
+// Ready is called once all pipelines have started, right before the collector is running.
+func (r *readiness) Ready() error {
+	r.readyOnce.Do(func() { close(r.ready) })
+	return nil
+}
+
+func (r *readiness) NotReady() error {
+	return nil
+}
+
+// ComponentStatusChanged records how long each component took to start.
+func (r *readiness) ComponentStatusChanged(source *componentstatus.InstanceID, event *componentstatus.Event) {
+	if source.ComponentID().Type() == readinessType {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.Status() == componentstatus.StatusStarting {
+		r.starting[source] = event.Timestamp()
+		return
+	}
+	start, ok := r.starting[source]
+	if !ok {
+		return
+	}
+	delete(r.starting, source)
+	name := componentName(source)
+	r.components = append(r.components, lambdalifecycle.StartupPhase{Name: name, Start: start, End: event.Timestamp()})
+	r.logger.Debug("Component started", zap.String("component", name), zap.String("status", event.Status().String()), zap.Duration("duration", event.Timestamp().Sub(start)))
+}
+
+// componentName returns the kind and ID of the component, e.g. "receiver/otlp". Processors are instantiated per
+// pipeline, so their pipeline is added, e.g. "processor/batch[traces]".
+func componentName(id *componentstatus.InstanceID) string {
+	name := fmt.Sprintf("%s/%s", strings.ToLower(id.Kind().String()), id.ComponentID())
+	if id.Kind() == component.KindProcessor {
+		id.AllPipelineIDs(func(pipelineID pipeline.ID) bool {
+			name += "[" + pipelineID.String() + "]"
+			return false
+		})
+	}
+	return name
+}
+
+// componentStartups returns the components that have started, in the order they did.
+func (r *readiness) componentStartups() []lambdalifecycle.StartupPhase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]lambdalifecycle.StartupPhase(nil), r.components...)
+}
+
+// newReadinessFactory returns the factory of the readiness extension. current returns the instance for the collector
+// being started, restarts the number of restarts reported by the lambda_collector_restarts metric of the collector's
+// own telemetry, and degraded the reasons reported by its lambda_extension_degraded metric.
+func newReadinessFactory(current func() *readiness, restarts func() int, degraded func() []string) extension.Factory {
+	return extension.NewFactory(
+		readinessType,
+		func() component.Config {
+			return &readinessConfig{}
+		},
+		func(_ context.Context, set extension.Settings, _ component.Config) (extension.Extension, error) {
+			if err := registerRestarts(set.MeterProvider, restarts); err != nil {
+				return nil, err
+			}
+			if err := registerDegraded(set.MeterProvider, degraded); err != nil {
+				return nil, err
+			}
+			return current(), nil
+		},
+		component.StabilityLevelStable,
+	)
+}
+
+// registerRestarts reports the number of restarts of the collector with meterProvider. The count is kept across
+// restarts, as every collector gets a new meter provider.
+func registerRestarts(meterProvider metric.MeterProvider, restarts func() int) error {
+	_, err := meterProvider.Meter(readinessScope).Int64ObservableCounter(
+		"lambda_collector_restarts",
+		metric.WithDescription("Number of times the collector was restarted after stopping unexpectedly."),
+		metric.WithUnit("{restarts}"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(restarts()))
+			return nil
+		}),
+	)
+	return err
+}
+
+// registerDegraded reports the reasons the extension runs in a degraded mode with meterProvider, as a gauge with a
+// data point per reason. Nothing is reported while the extension is not degraded.
+func registerDegraded(meterProvider metric.MeterProvider, degraded func() []string) error {
+	_, err := meterProvider.Meter(readinessScope).Int64ObservableGauge(
+		"lambda_extension_degraded",
+		metric.WithDescription("Whether the extension runs in a degraded mode, by reason."),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			for _, reason := range degraded() {
+				o.Observe(1, metric.WithAttributes(attribute.String("reason", reason)))
+			}
+			return nil
+		}),
+	)
+	return err
+}
+
+// readinessConverter adds the readiness extension to the configuration the collector runs. It is applied once the
+// configuration is resolved, so that the extension is not printed along with the configuration of the user.
+type readinessConverter struct{}
+
+func (readinessConverter) Convert(_ context.Context, conf *confmap.Conf) error {
+	id := readinessType.String()
+	extensions, _ := conf.Get("service::extensions").([]any)
+	extensions = append(extensions, id)
+	return conf.Merge(confmap.NewFromStringMap(map[string]any{
+		"extensions": map[string]any{id: nil},
+		"service":    map[string]any{"extensions": extensions},
+	}))
+}
@@ -0,0 +1,168 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/collector/confmap"
+)
+
+// redacted replaces the secrets of a printed configuration.
+const redacted = "[REDACTED]"
+
+// minSecretLength is the length from which a value retrieved by a secret provider is redacted wherever it appears.
+// Shorter values, such as flags or ports, are not secrets and would redact unrelated strings.
+const minSecretLength = 8
+
+// secretKeys are the parts of the keys whose values are redacted, such as the account_token of the logzio exporter or
+// the headers of the otlphttp exporter.
+var secretKeys = []string{"token", "api_key", "apikey", "password", "secret", "authorization", "headers"}
+
+// secrets records the values retrieved by the providers of secrets, such as env, ssm and secretsmanager, so that they
+// are redacted from the printed configuration. The tokens of the configuration generated by the logzio provider are
+// referenced as environment variables, so they are recorded too.
+type secrets struct {
+	mu     sync.Mutex
+	values map[string]struct{}
+}
+
+func newSecrets() *secrets {
+	return &secrets{values: map[string]struct{}{}}
+}
+
+// wrap returns a provider factory recording the values retrieved by the providers of f.
+func (s *secrets) wrap(f confmap.ProviderFactory) confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(set confmap.ProviderSettings) confmap.Provider {
+		return &recordingProvider{Provider: f.Create(set), secrets: s}
+	})
+}
+
+func (s *secrets) record(val any) {
+	switch v := val.(type) {
+	case string:
+		if len(v) >= minSecretLength {
+			s.mu.Lock()
+			s.values[v] = struct{}{}
+			s.mu.Unlock()
+		}
+	case map[string]any:
+		for _, item := range v {
+			s.record(item)
+		}
+	case []any:
+		for _, item := range v {
+			s.record(item)
+		}
+	}
+}
+
+// contains returns whether str contains a recorded secret.
+func (s *secrets) contains(str string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for secret := range s.values {
+		if strings.Contains(str, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns a copy of the configuration conf where the values of secret keys and the strings containing a
+// recorded secret are redacted.
+func (s *secrets) redact(conf map[string]any) map[string]any {
+	return s.redactValue("", conf).(map[string]any)
+}
+
+func (s *secrets) redactValue(key string, val any) any {
+	if isSecretKey(key) {
+		return redactAll(val)
+	}
+	switch v := val.(type) {
+	case string:
+		if s.contains(v) {
+			return redacted
+		}
+		return v
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = s.redactValue(k, item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = s.redactValue("", item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// redactAll redacts every value of val, keeping the keys of maps, such as the names of headers.
+func redactAll(val any) any {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = redactAll(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactAll(item)
+		}
+		return out
+	default:
+		return redacted
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordingProvider records the values its provider retrieves as secrets.
+type recordingProvider struct {
+	confmap.Provider
+	secrets *secrets
+}
+
+func (p *recordingProvider) Retrieve(ctx context.Context, uri string, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	retrieved, err := p.Provider.Retrieve(ctx, uri, watcher)
+	if err != nil {
+		return nil, err
+	}
+	val, err := retrieved.AsRaw()
+	if err != nil {
+		return nil, err
+	}
+	p.secrets.record(val)
+	return retrieved, nil
+}
@@ -0,0 +1,178 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/otelcol"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// staticScheme is the scheme of the provider returning a configuration held in memory, such as the configuration
+// resolved by the collector.
+const staticScheme = "static"
+
+// ConfigReloadInterval returns how often the configuration is resolved again to check for changes, as set by the
+// OPENTELEMETRY_EXTENSION_CONFIG_RELOAD_INTERVAL environment variable. Zero disables the check.
+func ConfigReloadInterval(logger *zap.Logger) time.Duration {
+	val, ex := os.LookupEnv("OPENTELEMETRY_EXTENSION_CONFIG_RELOAD_INTERVAL")
+	if !ex {
+		return 0
+	}
+	interval, err := time.ParseDuration(val)
+	if err != nil || interval < 0 {
+		logger.Warn("Invalid config reload interval, the configuration is not reloaded periodically", zap.String("interval", val))
+		return 0
+	}
+	return interval
+}
+
+// ConfigReloadInvocations returns after how many invocations the configuration is resolved again to check for
+// changes, as set by the OPENTELEMETRY_EXTENSION_CONFIG_RELOAD_INVOCATIONS environment variable. Zero disables the
+// check.
+func ConfigReloadInvocations(logger *zap.Logger) int {
+	val, ex := os.LookupEnv("OPENTELEMETRY_EXTENSION_CONFIG_RELOAD_INVOCATIONS")
+	if !ex {
+		return 0
+	}
+	invocations, err := strconv.Atoi(val)
+	if err != nil || invocations < 0 {
+		logger.Warn("Invalid config reload invocations, the configuration is not reloaded after invocations", zap.String("invocations", val))
+		return 0
+	}
+	return invocations
+}
+
+// resolveConfig resolves the configuration from the config URI, with the converters applied.
+func (c *Collector) resolveConfig(ctx context.Context) (*confmap.Conf, error) {
+	return c.resolve(ctx, c.cfgProSet.ResolverSettings)
+}
+
+func (c *Collector) resolve(ctx context.Context, settings confmap.ResolverSettings) (*confmap.Conf, error) {
+	resolver, err := confmap.NewResolver(settings)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to create the config resolver: %w", ErrInvalidConfig, err)
+	}
+	conf, err := resolver.Resolve(ctx)
+	if shutdownErr := resolver.Shutdown(ctx); shutdownErr != nil {
+		c.logger.Warn("Failed to shut down the config resolver", zap.Error(shutdownErr))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get config: %w", ErrInvalidConfig, err)
+	}
+	return conf, nil
+}
+
+// Reload resolves the configuration again and, if it changed, restarts the collector with it. The new configuration
+// is validated before the running collector is stopped, and the previous configuration is started again if the new
+// one fails to start. Reload returns whether the collector was restarted, which is the case even if err is not nil
+// when the previous configuration had to be restored.
+func (c *Collector) Reload(ctx context.Context) (bool, error) {
+	conf, err := c.resolveConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(conf.ToStringMap(), c.conf.ToStringMap()) {
+		return false, nil
+	}
+
+	if err = c.validate(ctx, conf); err != nil {
+		return false, err
+	}
+
+	if err = c.Stop(ctx); err != nil {
+		return false, fmt.Errorf("failed to stop the collector: %w", err)
+	}
+	if err = c.start(ctx, conf); err != nil {
+		c.logger.Warn("Failed to start the collector with the new configuration, restoring the previous one", zap.Error(err))
+		if restoreErr := c.start(ctx, c.conf); restoreErr != nil {
+			return true, multierr.Combine(err, fmt.Errorf("failed to restore the previous configuration: %w", restoreErr))
+		}
+		return true, err
+	}
+	c.setConf(conf)
+	c.setConfigFallbackError(nil)
+	return true, nil
+}
+
+// resolvedConfigSettings returns the settings of a config provider returning conf. The configuration is resolved
+// beforehand, so that restarting the collector neither fetches it again nor applies the converters twice. Only the
+// readiness extension is added, so that it is not part of the resolved configuration.
+func resolvedConfigSettings(conf *confmap.Conf) otelcol.ConfigProviderSettings {
+	// The resolver expands the retrieved configuration again, so "$" are escaped to be kept as is.
+	escaped := escapeDollars(conf.ToStringMap())
+	return otelcol.ConfigProviderSettings{
+		ResolverSettings: confmap.ResolverSettings{
+			URIs:              []string{staticScheme + ":config"},
+			ProviderFactories: []confmap.ProviderFactory{newStaticProviderFactory(escaped)},
+			ConverterFactories: []confmap.ConverterFactory{confmap.NewConverterFactory(func(confmap.ConverterSettings) confmap.Converter {
+				return readinessConverter{}
+			})},
+		},
+	}
+}
+
+func escapeDollars(val any) any {
+	switch v := val.(type) {
+	case string:
+		return strings.ReplaceAll(v, "$", "$$")
+	case map[string]any:
+		escaped := make(map[string]any, len(v))
+		for key, item := range v {
+			escaped[key] = escapeDollars(item)
+		}
+		return escaped
+	case []any:
+		escaped := make([]any, len(v))
+		for i, item := range v {
+			escaped[i] = escapeDollars(item)
+		}
+		return escaped
+	default:
+		return v
+	}
+}
+
+// staticProvider returns a configuration held in memory, whatever the URI.
+type staticProvider struct {
+	conf any
+}
+
+func newStaticProviderFactory(conf any) confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(confmap.ProviderSettings) confmap.Provider {
+		return staticProvider{conf: conf}
+	})
+}
+
+func (p staticProvider) Retrieve(context.Context, string, confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	return confmap.NewRetrieved(p.conf)
+}
+
+func (staticProvider) Scheme() string {
+	return staticScheme
+}
+
+func (staticProvider) Shutdown(context.Context) error {
+	return nil
+}
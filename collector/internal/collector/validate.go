@@ -0,0 +1,55 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/otelcol"
+)
+
+// Validate resolves the configuration and validates it against the components of the build, without starting the
+// collector. The returned error wraps ErrInvalidConfig when the configuration is invalid.
+func (c *Collector) Validate(ctx context.Context) error {
+	conf, err := c.resolveConfig(ctx)
+	if err != nil {
+		return err
+	}
+	return c.validate(ctx, conf)
+}
+
+// validate validates the resolved configuration conf against the components of the build.
+func (c *Collector) validate(ctx context.Context, conf *confmap.Conf) error {
+	svc, err := otelcol.NewCollector(c.settings(conf))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err = svc.DryRun(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return nil
+}
+
+// ResolveEffectiveConfig resolves the configuration as the collector would on start, and returns it as YAML without
+// starting the collector. The secrets referenced by the configuration are redacted.
+func (c *Collector) ResolveEffectiveConfig(ctx context.Context) ([]byte, error) {
+	conf, err := c.resolveConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.marshalRedacted(conf)
+}
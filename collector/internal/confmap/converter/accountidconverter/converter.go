@@ -0,0 +1,85 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The accountidconverter implements the Converter adding the AWS account ID of the function to the resource of every
+// signal the collector exports. A resource processor inserting cloud.account.id is declared and put first in every
+// traces, metrics and logs pipeline, so that the telemetry of the function received over OTLP carries it as well as
+// the telemetry of the receivers of the extension. A value set by the function is kept.
+package accountidconverter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/confmap"
+)
+
+const (
+	serviceKey    = "service"
+	pipelinesKey  = "pipelines"
+	processorsKey = "processors"
+
+	// ProcessorID is the resource processor added to the pipelines.
+	ProcessorID = "resource/lambda_account_id"
+
+	accountIDAttribute = "cloud.account.id"
+)
+
+// signals are the pipeline types the resource processor supports.
+var signals = []string{"traces", "metrics", "logs"}
+
+type converter struct {
+	accountID func() string
+}
+
+// New returns a confmap.Converter adding the account ID returned by accountID to every pipeline. The configuration is
+// left unchanged when the account ID is unknown.
+func New(accountID func() string) confmap.Converter {
+	return &converter{accountID: accountID}
+}
+
+func (c converter) Convert(_ context.Context, conf *confmap.Conf) error {
+	accountID := c.accountID()
+	if accountID == "" {
+		return nil
+	}
+	pipelines, ok := conf.Get(fmt.Sprintf("%s::%s", serviceKey, pipelinesKey)).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	updates := make(map[string]interface{})
+	for name, pipelineVal := range pipelines {
+		if !slices.Contains(signals, strings.Split(name, "/")[0]) {
+			continue
+		}
+		pipeline, _ := pipelineVal.(map[string]interface{})
+		existing, _ := pipeline[processorsKey].([]interface{})
+		if slices.Contains(existing, interface{}(ProcessorID)) {
+			continue
+		}
+		updates[fmt.Sprintf("%s::%s::%s::%s", serviceKey, pipelinesKey, name, processorsKey)] = append([]interface{}{ProcessorID}, existing...)
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	updates[fmt.Sprintf("%s::%s", processorsKey, ProcessorID)] = map[string]interface{}{
+		"attributes": []interface{}{
+			map[string]interface{}{"key": accountIDAttribute, "value": accountID, "action": "insert"},
+		},
+	}
+	return conf.Merge(confmap.NewFromStringMap(updates))
+}
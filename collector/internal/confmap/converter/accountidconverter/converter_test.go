@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accountidconverter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+func TestConvert(t *testing.T) {
+	input := map[string]interface{}{
+		"service": map[string]interface{}{
+			"pipelines": map[string]interface{}{
+				"traces":         map[string]interface{}{"processors": []interface{}{"batch"}},
+				"logs/telemetry": map[string]interface{}{},
+				"profiles":       map[string]interface{}{},
+			},
+		},
+	}
+	resource := map[string]interface{}{
+		"attributes": []interface{}{
+			map[string]interface{}{"key": "cloud.account.id", "value": "123456789012", "action": "insert"},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		accountID string
+		input     map[string]interface{}
+		expected  map[string]interface{}
+	}{
+		{
+			name:      "unknown account ID",
+			accountID: "",
+			input:     input,
+			expected:  input,
+		},
+		{
+			name:      "no service",
+			accountID: "123456789012",
+			input:     map[string]interface{}{},
+			expected:  map[string]interface{}{},
+		},
+		{
+			name:      "added first to every pipeline",
+			accountID: "123456789012",
+			input:     input,
+			expected: map[string]interface{}{
+				"processors": map[string]interface{}{ProcessorID: resource},
+				"service": map[string]interface{}{
+					"pipelines": map[string]interface{}{
+						"traces":         map[string]interface{}{"processors": []interface{}{ProcessorID, "batch"}},
+						"logs/telemetry": map[string]interface{}{"processors": []interface{}{ProcessorID}},
+						"profiles":       map[string]interface{}{},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := confmap.NewFromStringMap(tc.input)
+			c := New(func() string { return tc.accountID })
+			if err := c.Convert(context.Background(), conf); err != nil {
+				t.Errorf("unexpected error converting: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, conf.ToStringMap()); diff != "" {
+				t.Errorf("Convert() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
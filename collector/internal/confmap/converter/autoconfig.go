@@ -0,0 +1,205 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package converter holds the auto-configuration of the Lambda collector: an ordered list of converters adapting the
+// user configuration to the Lambda environment, each of which can be disabled.
+package converter
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/otelcol"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/accountidconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/decoupleafterbatchconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/disablequeuedretryconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/lintconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/memoryconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/otlponlyconverter"
+)
+
+// DisabledEnv lists the names of the auto-configuration steps to skip, separated by commas. "all" disables every step.
+const DisabledEnv = "OPENTELEMETRY_EXTENSION_AUTO_CONFIG_DISABLED"
+
+const (
+	// OTLPOnly removes the telemetryapireceiver when the Telemetry API is unavailable, see otlponlyconverter.
+	OTLPOnly = "otlp_only"
+	// DisableQueuedRetry disables the sending queue of exporters, see disablequeuedretryconverter.
+	DisableQueuedRetry = "disable_queued_retry"
+	// DecoupleAfterBatch adds the decouple processor after the batch processor, see decoupleafterbatchconverter.
+	DecoupleAfterBatch = "decouple_after_batch"
+	// AccountID adds the AWS account ID of the function to the resource of every pipeline, see accountidconverter.
+	AccountID = "account_id"
+	// Memory sizes the collector to the memory of the function, see memoryconverter.
+	Memory = "memory"
+	// Lint reports the parts of the configuration that do not suit Lambda, see lintconverter. It runs last, on the
+	// configuration as adapted by the other steps.
+	Lint = "lint"
+)
+
+var (
+	decoupleProcessorType      = component.MustNewType("decouple")
+	memoryLimiterProcessorType = component.MustNewType("memory_limiter")
+	resourceProcessorType      = component.MustNewType("resource")
+)
+
+// Step is a named converter of the auto-configuration.
+type Step struct {
+	Name string
+	New  func(set confmap.ConverterSettings) confmap.Converter
+	// Available returns why the step cannot run in this build, if it cannot. It is optional.
+	Available func() error
+}
+
+// AutoConfig is the ordered list of steps adapting the configuration to Lambda. Steps run in order, each on the
+// output of the previous one.
+type AutoConfig []Step
+
+// Options configures the steps of the default auto-configuration.
+type Options struct {
+	// QueueMode selects how the sending queue of exporters is handled.
+	QueueMode disablequeuedretryconverter.Mode
+	// Factories are the components compiled into the collector. Steps adding components are skipped if these are
+	// missing, and the queue settings of exporters are found in their default configuration.
+	Factories otelcol.Factories
+	// FunctionMemoryMB is the memory of the function, or zero if unknown.
+	FunctionMemoryMB int
+	// ExtensionMemoryMiB is the share of the function memory the extension is limited to.
+	ExtensionMemoryMiB int
+	// AccountID returns the AWS account ID of the function, or an empty string if it is unknown. It is called when the
+	// configuration is resolved, once the extension has registered. It is optional.
+	AccountID func() string
+	// Degraded returns whether the extension runs without the Telemetry API. It is called when the configuration is
+	// resolved. It is optional.
+	Degraded func() bool
+	// LintMode selects whether configuration issues are logged or fail the configuration.
+	LintMode lintconverter.Mode
+}
+
+// Default returns the default auto-configuration.
+func Default(opts Options) AutoConfig {
+	return AutoConfig{
+		{
+			Name: OTLPOnly,
+			New: func(set confmap.ConverterSettings) confmap.Converter {
+				return otlponlyconverter.New(set, opts.Degraded)
+			},
+			Available: func() error {
+				if opts.Degraded == nil {
+					return errors.New("the degraded mode of the extension is not available")
+				}
+				return nil
+			},
+		},
+		{
+			Name: DisableQueuedRetry,
+			New: func(set confmap.ConverterSettings) confmap.Converter {
+				return disablequeuedretryconverter.NewWithMode(set, opts.QueueMode, opts.Factories.Exporters)
+			},
+		},
+		{
+			Name: DecoupleAfterBatch,
+			New: func(confmap.ConverterSettings) confmap.Converter {
+				return decoupleafterbatchconverter.New()
+			},
+			Available: func() error {
+				if _, ok := opts.Factories.Processors[decoupleProcessorType]; !ok {
+					return errors.New("the decouple processor is not part of this build")
+				}
+				return nil
+			},
+		},
+		{
+			Name: AccountID,
+			New: func(confmap.ConverterSettings) confmap.Converter {
+				return accountidconverter.New(opts.AccountID)
+			},
+			Available: func() error {
+				if opts.AccountID == nil {
+					return errors.New("the account ID of the function is not available")
+				}
+				if _, ok := opts.Factories.Processors[resourceProcessorType]; !ok {
+					return errors.New("the resource processor is not part of this build")
+				}
+				return nil
+			},
+		},
+		{
+			Name: Memory,
+			New: func(confmap.ConverterSettings) confmap.Converter {
+				_, memoryLimiter := opts.Factories.Processors[memoryLimiterProcessorType]
+				return memoryconverter.New(memoryconverter.Config{
+					FunctionMemoryMB:   opts.FunctionMemoryMB,
+					ExtensionMemoryMiB: opts.ExtensionMemoryMiB,
+					MemoryLimiter:      memoryLimiter,
+				})
+			},
+			Available: func() error {
+				if opts.FunctionMemoryMB <= 0 || opts.ExtensionMemoryMiB <= 0 {
+					return errors.New("the memory of the function is unknown")
+				}
+				return nil
+			},
+		},
+		{
+			Name: Lint,
+			New: func(set confmap.ConverterSettings) confmap.Converter {
+				return lintconverter.New(set, opts.LintMode)
+			},
+		},
+	}
+}
+
+// Factories returns the factories of the steps that are not disabled by the DisabledEnv environment variable, in
+// order.
+func (a AutoConfig) Factories(logger *zap.Logger) []confmap.ConverterFactory {
+	disabled := disabledSteps(os.Getenv(DisabledEnv))
+	var factories []confmap.ConverterFactory
+	for _, step := range a {
+		if slices.Contains(disabled, "all") || slices.Contains(disabled, step.Name) {
+			logger.Info("Auto-configuration step disabled", zap.String("step", step.Name))
+			continue
+		}
+		if step.Available != nil {
+			if err := step.Available(); err != nil {
+				logger.Warn("Auto-configuration step unavailable", zap.String("step", step.Name), zap.Error(err))
+				continue
+			}
+		}
+		logger.Debug("Auto-configuration step enabled", zap.String("step", step.Name))
+		factories = append(factories, confmap.NewConverterFactory(step.New))
+	}
+	for _, name := range disabled {
+		if name != "all" && !slices.ContainsFunc(a, func(step Step) bool { return step.Name == name }) {
+			logger.Warn("Unknown auto-configuration step", zap.String("step", name), zap.String("env", DisabledEnv))
+		}
+	}
+	return factories
+}
+
+func disabledSteps(val string) []string {
+	var names []string
+	for _, name := range strings.Split(val, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,154 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/logzioexporter"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusremotewriteexporter"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/resourceprocessor"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/provider/envprovider"
+	"go.opentelemetry.io/collector/confmap/provider/fileprovider"
+	"go.opentelemetry.io/collector/confmap/provider/yamlprovider"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/debugexporter"
+	"go.opentelemetry.io/collector/exporter/otlpexporter"
+	"go.opentelemetry.io/collector/exporter/otlphttpexporter"
+	"go.opentelemetry.io/collector/otelcol"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/batchprocessor"
+	"go.opentelemetry.io/collector/processor/memorylimiterprocessor"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/disablequeuedretryconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/confmap/converter/lintconverter"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor"
+)
+
+func resolve(t *testing.T, file string, converters []confmap.ConverterFactory) map[string]any {
+	resolver, err := confmap.NewResolver(confmap.ResolverSettings{
+		URIs:               []string{"file:" + filepath.Join("testdata", file)},
+		ProviderFactories:  []confmap.ProviderFactory{fileprovider.NewFactory(), envprovider.NewFactory()},
+		ConverterFactories: converters,
+	})
+	require.NoError(t, err)
+	conf, err := resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	return conf.ToStringMap()
+}
+
+// TestEffectiveConfig shows the configuration the collector runs for common user configurations.
+func TestEffectiveConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "otlp with batch",
+			config:   "otlp_batch.yaml",
+			expected: "otlp_batch_expected.yaml",
+		},
+		{
+			name:     "otlp with batch and drained queues",
+			config:   "otlp_batch.yaml",
+			opts:     Options{QueueMode: disablequeuedretryconverter.ModeDrain},
+			expected: "otlp_batch_drain_expected.yaml",
+		},
+		{
+			name:     "otlp with batch in a 128MB function",
+			config:   "otlp_batch.yaml",
+			opts:     Options{FunctionMemoryMB: 128, ExtensionMemoryMiB: 32},
+			expected: "otlp_batch_128mb_expected.yaml",
+		},
+		{
+			name:     "otlp with batch and an account ID",
+			config:   "otlp_batch.yaml",
+			opts:     Options{AccountID: func() string { return "123456789012" }},
+			expected: "otlp_batch_account_id_expected.yaml",
+		},
+		{
+			name:     "logz.io",
+			config:   "logzio.yaml",
+			expected: "logzio_expected.yaml",
+		},
+		{
+			name:     "already decoupled",
+			config:   "decoupled.yaml",
+			expected: "decoupled_expected.yaml",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Factories = testFactories(t)
+			got := resolve(t, tc.config, Default(tc.opts).Factories(zaptest.NewLogger(t)))
+			want := resolve(t, tc.expected, nil)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("effective config mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDisabledSteps(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	opts := Options{Factories: testFactories(t)}
+	require.Len(t, Default(opts).Factories(logger), 3)
+	// Without the decouple processor, it cannot be added.
+	require.Len(t, Default(Options{}).Factories(logger), 2)
+
+	t.Setenv(DisabledEnv, " decouple_after_batch, unknown")
+	got := resolve(t, "otlp_batch.yaml", Default(opts).Factories(logger))
+	require.Equal(t, []any{"batch"}, got["service"].(map[string]any)["pipelines"].(map[string]any)["traces"].(map[string]any)["processors"])
+
+	t.Setenv(DisabledEnv, "all")
+	require.Empty(t, Default(opts).Factories(logger))
+}
+
+func TestStrictLint(t *testing.T) {
+	opts := Options{Factories: testFactories(t), LintMode: lintconverter.ModeStrict}
+	resolve := func() error {
+		resolver, err := confmap.NewResolver(confmap.ResolverSettings{
+			// The metrics of the collector are not exposed, so that only the pipelines are linted.
+			URIs:               []string{"file:" + filepath.Join("testdata", "otlp_batch.yaml"), "yaml:service::telemetry::metrics::level: none"},
+			ProviderFactories:  []confmap.ProviderFactory{fileprovider.NewFactory(), yamlprovider.NewFactory()},
+			ConverterFactories: Default(opts).Factories(zaptest.NewLogger(t)),
+		})
+		require.NoError(t, err)
+		_, err = resolver.Resolve(context.Background())
+		return err
+	}
+	require.NoError(t, resolve())
+
+	// Without the decouple processor added after the batch processor, the configuration is rejected.
+	t.Setenv(DisabledEnv, DecoupleAfterBatch)
+	require.ErrorContains(t, resolve(), lintconverter.RuleBatchWithoutDecouple)
+}
+
+func testFactories(t *testing.T) otelcol.Factories {
+	processors, err := otelcol.MakeFactoryMap[processor.Factory](batchprocessor.NewFactory(), decoupleprocessor.NewFactory(), memorylimiterprocessor.NewFactory(),
+		resourceprocessor.NewFactory())
+	require.NoError(t, err)
+	exporters, err := otelcol.MakeFactoryMap[exporter.Factory](debugexporter.NewFactory(), otlpexporter.NewFactory(), otlphttpexporter.NewFactory(),
+		logzioexporter.NewFactory(), prometheusremotewriteexporter.NewFactory())
+	require.NoError(t, err)
+	return otelcol.Factories{Processors: processors, Exporters: exporters}
+}
@@ -22,6 +22,7 @@ package decoupleafterbatchconverter
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"go.opentelemetry.io/collector/confmap"
@@ -33,6 +34,8 @@ const (
 	processorsKey     = "processors"
 	batchProcessor    = "batch"
 	decoupleProcessor = "decouple"
+	// autoDecoupleProcessor is appended to pipelines that already use the decouple processor before a batch processor.
+	autoDecoupleProcessor = decoupleProcessor + "/auto"
 )
 
 type converter struct{}
@@ -80,11 +83,19 @@ func (c converter) Convert(_ context.Context, conf *confmap.Conf) error {
 
 		// accumulate config updates
 		if shouldAppendDecouple(processors) {
-			processors = append(processors, decoupleProcessor)
+			// A pipeline cannot reference the same processor twice, so another instance is added if the pipeline
+			// already uses the decouple processor before the batch processor.
+			id := decoupleProcessor
+			if slices.Contains(processors, interface{}(decoupleProcessor)) {
+				id = autoDecoupleProcessor
+			}
+			processors = append(processors, id)
 			updates[fmt.Sprintf("%s::%s::%s::%s", serviceKey, pipelinesKey, telemetryType, processorsKey)] = processors
-			break
+			// The processor must be declared to be used in a pipeline.
+			if !conf.IsSet(fmt.Sprintf("%s::%s", processorsKey, id)) {
+				updates[fmt.Sprintf("%s::%s", processorsKey, id)] = nil
+			}
 		}
-
 	}
 
 	// apply all updates
@@ -37,6 +37,15 @@ func TestConvert(t *testing.T) {
 		})
 	}
 
+	// convertedConf is the expected configuration when the decouple processor with the given ID is added.
+	convertedConf := func(processors []interface{}, added string) *confmap.Conf {
+		conf := baseConf(processors)
+		_ = conf.Merge(confmap.NewFromStringMap(map[string]interface{}{
+			"processors": map[string]interface{}{added: nil},
+		}))
+		return conf
+	}
+
 	testCases := []struct {
 		name     string
 		input    *confmap.Conf
@@ -99,12 +108,12 @@ func TestConvert(t *testing.T) {
 		{
 			name:     "batch processor in singleton chain",
 			input:    baseConf([]interface{}{"batch"}),
-			expected: baseConf([]interface{}{"batch", "decouple"}),
+			expected: convertedConf([]interface{}{"batch", "decouple"}, "decouple"),
 		},
 		{
 			name:     "batch processor present twice",
 			input:    baseConf([]interface{}{"batch", "processor1", "batch"}),
-			expected: baseConf([]interface{}{"batch", "processor1", "batch", "decouple"}),
+			expected: convertedConf([]interface{}{"batch", "processor1", "batch", "decouple"}, "decouple"),
 		},
 
 		{
@@ -115,7 +124,7 @@ func TestConvert(t *testing.T) {
 		{
 			name:     "batch sandwiched between input no decouple",
 			input:    baseConf([]interface{}{"processor1", "batch", "processor2"}),
-			expected: baseConf([]interface{}{"processor1", "batch", "processor2", "decouple"}),
+			expected: convertedConf([]interface{}{"processor1", "batch", "processor2", "decouple"}, "decouple"),
 		},
 
 		{
@@ -126,12 +135,35 @@ func TestConvert(t *testing.T) {
 		{
 			name:     "decouple and batch",
 			input:    baseConf([]interface{}{"decouple", "batch"}),
-			expected: baseConf([]interface{}{"decouple", "batch", "decouple"}),
+			expected: convertedConf([]interface{}{"decouple", "batch", "decouple/auto"}, "decouple/auto"),
 		},
 		{
 			name:     "decouple then batch mixed with others in the pipelinefirst then batch somewhere",
 			input:    baseConf([]interface{}{"processor1", "decouple", "processor2", "batch", "processor3"}),
-			expected: baseConf([]interface{}{"processor1", "decouple", "processor2", "batch", "processor3", "decouple"}),
+			expected: convertedConf([]interface{}{"processor1", "decouple", "processor2", "batch", "processor3", "decouple/auto"}, "decouple/auto"),
+		},
+		{
+			name: "every pipeline is converted",
+			input: confmap.NewFromStringMap(map[string]interface{}{
+				"processors": map[string]interface{}{"batch": nil, "decouple": map[string]interface{}{"max_queue_size": 100}},
+				"service": map[string]interface{}{
+					"pipelines": map[string]interface{}{
+						"traces":  map[string]interface{}{"processors": []interface{}{"batch"}},
+						"metrics": map[string]interface{}{"processors": []interface{}{"batch"}},
+						"logs":    map[string]interface{}{"processors": []interface{}{"batch", "decouple"}},
+					},
+				},
+			}),
+			expected: confmap.NewFromStringMap(map[string]interface{}{
+				"processors": map[string]interface{}{"batch": nil, "decouple": map[string]interface{}{"max_queue_size": 100}},
+				"service": map[string]interface{}{
+					"pipelines": map[string]interface{}{
+						"traces":  map[string]interface{}{"processors": []interface{}{"batch", "decouple"}},
+						"metrics": map[string]interface{}{"processors": []interface{}{"batch", "decouple"}},
+						"logs":    map[string]interface{}{"processors": []interface{}{"batch", "decouple"}},
+					},
+				},
+			}),
 		},
 	}
 
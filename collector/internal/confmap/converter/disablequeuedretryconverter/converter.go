@@ -16,67 +16,130 @@ package disablequeuedretryconverter // import "github.com/open-telemetry/opentel
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
+	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/exporter"
+	"go.uber.org/zap"
 )
 
 const (
 	expKey = "exporters"
 )
 
-var exporters = map[string]struct{}{
-	"awskinesis":              {},
-	"coralogix":               {},
-	"datadog":                 {},
-	"dynatrace":               {},
-	"googlecloud":             {},
-	"googlecloudpubsub":       {},
-	"googlemanagedprometheus": {},
-	"humio":                   {},
-	"influxdb":                {},
-	"jaeger":                  {},
-	"kafka":                   {},
-	"logzio":                  {},
-	"loki":                    {},
-	"mezmo":                   {},
-	"observiq":                {},
-	"opencensus":              {},
-	"otlp":                    {},
-	"otlphttp":                {},
-	"pulsar":                  {},
-	"sapm":                    {},
-	"signalfx":                {},
-	"skywalking":              {},
-	"splunkhec":               {},
-	"sumologic":               {},
-	"tanzuobservability":      {},
-	"zipkin":                  {},
+// Mode selects how the sending queue of exporters is handled.
+type Mode string
+
+const (
+	// ModeDisable disables the sending queue of exporters, so that exports complete before the environment is frozen.
+	ModeDisable Mode = "disable"
+	// ModeDrain keeps the sending queue, retry and backoff of exporters as configured. The queues are drained by the
+	// lifecycle manager before the environment is frozen or shut down.
+	ModeDrain Mode = "drain"
+)
+
+// ParseMode returns the Mode matching the given value. An empty value selects ModeDisable.
+func ParseMode(val string) (Mode, error) {
+	switch Mode(strings.ToLower(val)) {
+	case "", ModeDisable:
+		return ModeDisable, nil
+	case ModeDrain:
+		return ModeDrain, nil
+	default:
+		return ModeDisable, fmt.Errorf("unknown exporter queue mode %q, expected %q or %q", val, ModeDisable, ModeDrain)
+	}
 }
 
 type converter struct {
+	mode   Mode
+	logger *zap.Logger
+	// queues are the keys of the queue settings of each exporter type.
+	queues map[component.Type][]string
 }
 
 // New returns a confmap.Converter, that ensures queued retry is disabled for all configured exporters.
-func New() confmap.Converter {
-	return &converter{}
+func New(set confmap.ConverterSettings, factories map[component.Type]exporter.Factory) confmap.Converter {
+	return NewWithMode(set, ModeDisable, factories)
+}
+
+// NewWithMode returns a confmap.Converter handling the sending queue of exporters according to the given mode. The
+// queue settings of each exporter type are found in the default configuration of its factory.
+func NewWithMode(set confmap.ConverterSettings, mode Mode, factories map[component.Type]exporter.Factory) confmap.Converter {
+	logger := set.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	queues := make(map[component.Type][]string, len(factories))
+	for typ, factory := range factories {
+		keys, err := queueKeys(factory.CreateDefaultConfig())
+		if err != nil {
+			logger.Warn("Failed to inspect the default configuration of exporter", zap.String("exporter", typ.String()), zap.Error(err))
+			continue
+		}
+		if len(keys) > 0 {
+			queues[typ] = keys
+		}
+	}
+	return &converter{mode: mode, logger: logger, queues: queues}
+}
+
+// queueKeys returns the top-level keys of cfg holding a queue setting, such as "sending_queue". A queue setting is
+// recognized by its "enabled" and "queue_size" keys.
+func queueKeys(cfg component.Config) ([]string, error) {
+	conf := confmap.New()
+	if err := conf.Marshal(cfg); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for key, val := range conf.ToStringMap() {
+		settings, ok := val.(map[string]any)
+		if !ok {
+			continue
+		}
+		_, enabled := settings["enabled"]
+		_, size := settings["queue_size"]
+		if enabled && size {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
 }
 
 func (c converter) Convert(_ context.Context, conf *confmap.Conf) error {
+	if c.mode == ModeDrain {
+		return nil
+	}
+
 	out := make(map[string]interface{})
+	var changed []string
 	expVal := conf.Get(expKey)
 
 	switch exps := expVal.(type) {
 	case map[string]interface{}:
 		for name := range exps {
-			if _, ok := exporters[strings.Split(name, "/")[0]]; !ok {
+			typ, err := component.NewType(strings.Split(name, "/")[0])
+			if err != nil {
+				continue
+			}
+			keys, ok := c.queues[typ]
+			if !ok {
 				continue
 			}
-			out[fmt.Sprintf("%s::%s::sending_queue::enabled", expKey, name)] = false
+			for _, key := range keys {
+				out[fmt.Sprintf("%s::%s::%s::enabled", expKey, name, key)] = false
+			}
+			changed = append(changed, name)
 		}
 	}
 	if err := conf.Merge(confmap.NewFromStringMap(out)); err != nil {
 		return err
 	}
+	if len(changed) > 0 {
+		slices.Sort(changed)
+		c.logger.Info("Disabled the queue of exporters", zap.Strings("exporters", changed))
+	}
 	return nil
 }
@@ -19,9 +19,45 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/debugexporter"
+	"go.opentelemetry.io/collector/exporter/otlpexporter"
+	"go.opentelemetry.io/collector/exporter/otlphttpexporter"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+type customConfig struct {
+	Endpoint string      `mapstructure:"endpoint"`
+	Queue    queueConfig `mapstructure:"remote_write_queue"`
+	Retry    retryConfig `mapstructure:"retry"`
+}
+
+type queueConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	QueueSize int  `mapstructure:"queue_size"`
+}
+
+type retryConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// testFactories returns the otlp, otlphttp and debug exporters, and a custom exporter with a queue setting that is
+// not named sending_queue.
+func testFactories() map[component.Type]exporter.Factory {
+	custom := exporter.NewFactory(component.MustNewType("custom"), func() component.Config {
+		return &customConfig{Queue: queueConfig{Enabled: true, QueueSize: 100}, Retry: retryConfig{Enabled: true}}
+	})
+	factories := map[component.Type]exporter.Factory{}
+	for _, f := range []exporter.Factory{otlpexporter.NewFactory(), otlphttpexporter.NewFactory(), debugexporter.NewFactory(), custom} {
+		factories[f.Type()] = f
+	}
+	return factories
+}
+
 func TestConvert(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
@@ -37,14 +73,14 @@ func TestConvert(t *testing.T) {
 		},
 		{
 			name:     "no queuing exporters",
-			conf:     confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"prometheus": map[string]any{}}}),
-			expected: confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"prometheus": map[string]any{}}}),
+			conf:     confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"debug": map[string]any{}}}),
+			expected: confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"debug": map[string]any{}}}),
 			err:      nil,
 		},
 		{
 			name:     "some queuing exporters",
-			conf:     confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"prometheus": map[string]any{}, "otlp": map[string]any{}}}),
-			expected: confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"prometheus": map[string]any{}, "otlp": map[string]any{"sending_queue": map[string]any{"enabled": false}}}}),
+			conf:     confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"debug": map[string]any{}, "otlp": map[string]any{}}}),
+			expected: confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"debug": map[string]any{}, "otlp": map[string]any{"sending_queue": map[string]any{"enabled": false}}}}),
 			err:      nil,
 		},
 		{
@@ -53,12 +89,64 @@ func TestConvert(t *testing.T) {
 			expected: confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"otlphttp": map[string]any{"sending_queue": map[string]any{"enabled": false}}, "otlp": map[string]any{"sending_queue": map[string]any{"enabled": false}}}}),
 			err:      nil,
 		},
+		{
+			name:     "queue setting found in the default config",
+			conf:     confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"custom/1": map[string]any{"endpoint": "localhost"}}}),
+			expected: confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"custom/1": map[string]any{"endpoint": "localhost", "remote_write_queue": map[string]any{"enabled": false}}}}),
+			err:      nil,
+		},
+		{
+			name:     "unknown exporters",
+			conf:     confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"unknown": map[string]any{}}}),
+			expected: confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"unknown": map[string]any{}}}),
+			err:      nil,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			c := New()
+			c := New(confmap.ConverterSettings{}, testFactories())
 			err := c.Convert(context.Background(), tc.conf)
 			assert.Equal(t, err, tc.err)
 			assert.Equal(t, tc.conf, tc.expected)
 		})
 	}
 }
+
+func TestConvertDrainMode(t *testing.T) {
+	conf := confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"otlp": map[string]any{}}})
+	expected := confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"otlp": map[string]any{}}})
+
+	c := NewWithMode(confmap.ConverterSettings{}, ModeDrain, testFactories())
+	assert.NoError(t, c.Convert(context.Background(), conf))
+	assert.Equal(t, expected, conf)
+}
+
+func TestConvertLogsChangedExporters(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	conf := confmap.NewFromStringMap(map[string]any{"exporters": map[string]any{"otlphttp": map[string]any{}, "otlp/2": map[string]any{}, "debug": map[string]any{}}})
+
+	c := New(confmap.ConverterSettings{Logger: zap.New(core)}, testFactories())
+	require.NoError(t, c.Convert(context.Background(), conf))
+
+	entries := logs.FilterMessage("Disabled the queue of exporters").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, []any{"otlp/2", "otlphttp"}, entries[0].ContextMap()["exporters"])
+}
+
+func TestParseMode(t *testing.T) {
+	for _, tc := range []struct {
+		val      string
+		expected Mode
+		wantErr  bool
+	}{
+		{val: "", expected: ModeDisable},
+		{val: "disable", expected: ModeDisable},
+		{val: "DRAIN", expected: ModeDrain},
+		{val: "unknown", expected: ModeDisable, wantErr: true},
+	} {
+		t.Run(tc.val, func(t *testing.T) {
+			mode, err := ParseMode(tc.val)
+			assert.Equal(t, tc.expected, mode)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The lintconverter implements the Converter reporting configurations that do not suit the Lambda environment, such
+// as a batch processor without decouple processor after it. Issues are logged with a hint on how to fix them, or fail
+// the configuration in strict mode. The configuration is never changed.
+package lintconverter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+const (
+	serviceKey    = "service"
+	pipelinesKey  = "pipelines"
+	processorsKey = "processors"
+	receiversKey  = "receivers"
+
+	batchProcessor        = "batch"
+	decoupleProcessor     = "decouple"
+	tailSamplingProcessor = "tail_sampling"
+	telemetryAPIReceiver  = "telemetryapireceiver"
+
+	// defaultDecisionWait is the decision_wait of the tail_sampling processor when it is not set.
+	defaultDecisionWait = 30 * time.Second
+	// maxDecisionWait is the longest decision_wait that does not span several invocations in most functions.
+	maxDecisionWait = 5 * time.Second
+	// defaultTelemetryAPIPort is the port of the telemetryapireceiver when it is not set.
+	defaultTelemetryAPIPort = 4325
+)
+
+// Rules identifying the issues.
+const (
+	RuleBatchWithoutDecouple = "batch_without_decouple"
+	RuleTailSamplingWait     = "tail_sampling_decision_wait"
+	RuleReceiverAllAddresses = "receiver_all_addresses"
+	RuleTelemetryAPIPort     = "telemetry_api_port"
+	RuleTelemetryMetrics     = "telemetry_metrics_scrape"
+)
+
+// Mode selects what happens when issues are found.
+type Mode string
+
+const (
+	// ModeWarn logs the issues and keeps the configuration.
+	ModeWarn Mode = "warn"
+	// ModeStrict fails the configuration when issues are found.
+	ModeStrict Mode = "strict"
+)
+
+// ParseMode returns the Mode matching the given value. An empty value selects ModeWarn.
+func ParseMode(val string) (Mode, error) {
+	switch Mode(strings.ToLower(val)) {
+	case "", ModeWarn:
+		return ModeWarn, nil
+	case ModeStrict:
+		return ModeStrict, nil
+	default:
+		return ModeWarn, fmt.Errorf("unknown config lint mode %q, expected %q or %q", val, ModeWarn, ModeStrict)
+	}
+}
+
+// Issue is a part of the configuration that does not suit the Lambda environment.
+type Issue struct {
+	// Rule identifies the kind of issue, such as RuleBatchWithoutDecouple.
+	Rule string
+	// Component is the configuration key of the component or setting at fault.
+	Component string
+	// Problem describes the issue.
+	Problem string
+	// Hint describes how to fix it.
+	Hint string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s (hint: %s)", i.Rule, i.Component, i.Problem, i.Hint)
+}
+
+type converter struct {
+	mode   Mode
+	logger *zap.Logger
+}
+
+// New returns a confmap.Converter reporting the issues of the configuration according to the given mode.
+func New(set confmap.ConverterSettings, mode Mode) confmap.Converter {
+	logger := set.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &converter{mode: mode, logger: logger}
+}
+
+func (c converter) Convert(_ context.Context, conf *confmap.Conf) error {
+	issues := Lint(conf)
+	if c.mode == ModeStrict && len(issues) > 0 {
+		errs := make([]error, len(issues))
+		for i, issue := range issues {
+			errs[i] = errors.New(issue.String())
+		}
+		return fmt.Errorf("the configuration does not suit the Lambda environment: %w", errors.Join(errs...))
+	}
+	for _, issue := range issues {
+		c.logger.Warn("The configuration does not suit the Lambda environment",
+			zap.String("rule", issue.Rule),
+			zap.String("component", issue.Component),
+			zap.String("problem", issue.Problem),
+			zap.String("hint", issue.Hint))
+	}
+	return nil
+}
+
+// Lint returns the issues of the configuration, in a stable order.
+func Lint(conf *confmap.Conf) []Issue {
+	var issues []Issue
+	issues = append(issues, lintPipelines(conf)...)
+	issues = append(issues, lintProcessors(conf)...)
+	issues = append(issues, lintReceivers(conf)...)
+	issues = append(issues, lintTelemetry(conf)...)
+	return issues
+}
+
+// lintPipelines reports pipelines whose last batch processor is not followed by a decouple processor: the batch is
+// exported in the background, possibly after the environment is frozen.
+func lintPipelines(conf *confmap.Conf) []Issue {
+	pipelines, _ := conf.Get(fmt.Sprintf("%s::%s", serviceKey, pipelinesKey)).(map[string]interface{})
+	var issues []Issue
+	for _, name := range sortedKeys(pipelines) {
+		pipeline, _ := pipelines[name].(map[string]interface{})
+		processors, _ := pipeline[processorsKey].([]interface{})
+		lastBatch := -1
+		for i, p := range processors {
+			if baseName(fmt.Sprint(p)) == batchProcessor {
+				lastBatch = i
+			}
+		}
+		if lastBatch < 0 {
+			continue
+		}
+		decoupled := slices.ContainsFunc(processors[lastBatch+1:], func(p interface{}) bool {
+			return baseName(fmt.Sprint(p)) == decoupleProcessor
+		})
+		if !decoupled {
+			issues = append(issues, Issue{
+				Rule:      RuleBatchWithoutDecouple,
+				Component: fmt.Sprintf("%s::%s::%s", serviceKey, pipelinesKey, name),
+				Problem:   fmt.Sprintf("the %s processor is not followed by a decouple processor, batches can be exported after the environment is frozen", processors[lastBatch]),
+				Hint:      "add a decouple processor after the batch processor, or enable the decouple_after_batch auto-configuration step",
+			})
+		}
+	}
+	return issues
+}
+
+// lintProcessors reports tail_sampling processors waiting longer than an invocation usually lasts.
+func lintProcessors(conf *confmap.Conf) []Issue {
+	processors, _ := conf.Get(processorsKey).(map[string]interface{})
+	var issues []Issue
+	for _, name := range sortedKeys(processors) {
+		if baseName(name) != tailSamplingProcessor {
+			continue
+		}
+		wait := defaultDecisionWait
+		if val := conf.Get(fmt.Sprintf("%s::%s::decision_wait", processorsKey, name)); val != nil {
+			d, ok := duration(val)
+			if !ok {
+				continue
+			}
+			wait = d
+		}
+		if wait > maxDecisionWait {
+			issues = append(issues, Issue{
+				Rule:      RuleTailSamplingWait,
+				Component: fmt.Sprintf("%s::%s", processorsKey, name),
+				Problem:   fmt.Sprintf("the decision_wait of %s spans several invocations, traces are held while the environment is frozen and lost when it shuts down", wait),
+				Hint:      fmt.Sprintf("set decision_wait to %s or less, or sample in a collector outside of Lambda", maxDecisionWait),
+			})
+		}
+	}
+	return issues
+}
+
+// lintReceivers reports receivers listening on all addresses, and receivers listening on the port of the
+// telemetryapireceiver.
+func lintReceivers(conf *confmap.Conf) []Issue {
+	receivers, _ := conf.Get(receiversKey).(map[string]interface{})
+	telemetryAPIPorts := map[int]string{}
+	for _, name := range sortedKeys(receivers) {
+		if baseName(name) != telemetryAPIReceiver {
+			continue
+		}
+		port := defaultTelemetryAPIPort
+		if val := conf.Get(fmt.Sprintf("%s::%s::port", receiversKey, name)); val != nil {
+			p, err := strconv.Atoi(fmt.Sprint(val))
+			if err != nil {
+				continue
+			}
+			port = p
+		}
+		telemetryAPIPorts[port] = name
+	}
+
+	var issues []Issue
+	for _, name := range sortedKeys(receivers) {
+		for _, endpoint := range endpoints(fmt.Sprintf("%s::%s", receiversKey, name), receivers[name]) {
+			host, portVal, err := net.SplitHostPort(endpoint.value)
+			if err != nil {
+				continue
+			}
+			if host == "" || net.ParseIP(host).IsUnspecified() {
+				issues = append(issues, Issue{
+					Rule:      RuleReceiverAllAddresses,
+					Component: endpoint.key,
+					Problem:   fmt.Sprintf("the endpoint %q listens on all addresses, while only the function can reach the receiver", endpoint.value),
+					Hint:      "listen on localhost, for example localhost:" + portVal,
+				})
+			}
+			if port, err := strconv.Atoi(portVal); err == nil {
+				if other, ok := telemetryAPIPorts[port]; ok && baseName(name) != telemetryAPIReceiver {
+					issues = append(issues, Issue{
+						Rule:      RuleTelemetryAPIPort,
+						Component: endpoint.key,
+						Problem:   fmt.Sprintf("the endpoint %q uses port %d, which is the port of the %s receiver", endpoint.value, port, other),
+						Hint:      fmt.Sprintf("use another port, such as the OTLP defaults 4317 and 4318, or set the port of the %s receiver", other),
+					})
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// lintTelemetry reports internal metrics of the collector exposed to be scraped, which nobody can do in Lambda. Unless
+// the level is none, the collector exposes them on localhost:8888 when neither an address nor readers are configured.
+func lintTelemetry(conf *confmap.Conf) []Issue {
+	key := fmt.Sprintf("%s::telemetry::metrics", serviceKey)
+	metrics, _ := conf.Get(key).(map[string]interface{})
+	if level, _ := metrics["level"].(string); strings.EqualFold(level, "none") {
+		return nil
+	}
+	address, _ := metrics["address"].(string)
+	readers, _ := metrics["readers"].([]interface{})
+	scraped := address != "" || len(readers) == 0
+	for _, reader := range readers {
+		if r, ok := reader.(map[string]interface{}); ok && r["pull"] != nil {
+			scraped = true
+		}
+	}
+	if !scraped {
+		return nil
+	}
+	return []Issue{{
+		Rule:      RuleTelemetryMetrics,
+		Component: key,
+		Problem:   "the internal metrics of the collector are exposed to be scraped, which cannot be done while the environment is frozen",
+		Hint:      "push the metrics with a periodic reader and an OTLP exporter, or set the level to none",
+	}}
+}
+
+type endpoint struct {
+	key   string
+	value string
+}
+
+// endpoints returns the endpoint settings found anywhere in the configuration of a component.
+func endpoints(key string, val interface{}) []endpoint {
+	settings, ok := val.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var found []endpoint
+	for _, name := range sortedKeys(settings) {
+		childKey := fmt.Sprintf("%s::%s", key, name)
+		if s, ok := settings[name].(string); ok && name == "endpoint" {
+			found = append(found, endpoint{key: childKey, value: s})
+			continue
+		}
+		found = append(found, endpoints(childKey, settings[name])...)
+	}
+	return found
+}
+
+func duration(val interface{}) (time.Duration, bool) {
+	switch v := val.(type) {
+	case time.Duration:
+		return v, true
+	case string:
+		d, err := time.ParseDuration(v)
+		return d, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func baseName(name string) string {
+	return strings.Split(name, "/")[0]
+}
@@ -0,0 +1,238 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lintconverter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// noMetrics is the telemetry of a collector that does not expose its metrics, so that only the other rules are
+// checked by the test cases using it.
+var noMetrics = map[string]interface{}{"metrics": map[string]interface{}{"level": "none"}}
+
+func TestLint(t *testing.T) {
+	pipeline := func(processors ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"service": map[string]interface{}{
+				"pipelines": map[string]interface{}{
+					"traces": map[string]interface{}{"processors": processors},
+				},
+				"telemetry": noMetrics,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		input      map[string]interface{}
+		rules      []string
+		components []string
+	}{
+		{
+			name:  "empty",
+			input: map[string]interface{}{"service": map[string]interface{}{"telemetry": noMetrics}},
+		},
+		{
+			name:       "batch without decouple",
+			input:      pipeline("batch", "attributes"),
+			rules:      []string{RuleBatchWithoutDecouple},
+			components: []string{"service::pipelines::traces"},
+		},
+		{
+			name:  "batch followed by decouple",
+			input: pipeline("batch", "decouple/auto"),
+		},
+		{
+			name:       "decouple before the last batch",
+			input:      pipeline("batch/1", "decouple", "batch/2"),
+			rules:      []string{RuleBatchWithoutDecouple},
+			components: []string{"service::pipelines::traces"},
+		},
+		{
+			name: "tail sampling",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{"telemetry": noMetrics},
+				"processors": map[string]interface{}{
+					"tail_sampling":         map[string]interface{}{},
+					"tail_sampling/short":   map[string]interface{}{"decision_wait": "1s"},
+					"tail_sampling/long":    map[string]interface{}{"decision_wait": "10s"},
+					"tail_sampling/invalid": map[string]interface{}{"decision_wait": "soon"},
+				},
+			},
+			rules:      []string{RuleTailSamplingWait, RuleTailSamplingWait},
+			components: []string{"processors::tail_sampling", "processors::tail_sampling/long"},
+		},
+		{
+			name: "receivers on all addresses",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{"telemetry": noMetrics},
+				"receivers": map[string]interface{}{
+					"otlp": map[string]interface{}{
+						"protocols": map[string]interface{}{
+							"grpc": map[string]interface{}{"endpoint": "0.0.0.0:4317"},
+							"http": map[string]interface{}{"endpoint": "localhost:4318"},
+						},
+					},
+					"zipkin": map[string]interface{}{"endpoint": ":9411"},
+				},
+			},
+			rules:      []string{RuleReceiverAllAddresses, RuleReceiverAllAddresses},
+			components: []string{"receivers::otlp::protocols::grpc::endpoint", "receivers::zipkin::endpoint"},
+		},
+		{
+			name: "telemetry api port",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{"telemetry": noMetrics},
+				"receivers": map[string]interface{}{
+					"otlp": map[string]interface{}{
+						"protocols": map[string]interface{}{
+							"http": map[string]interface{}{"endpoint": "localhost:4325"},
+						},
+					},
+					"telemetryapireceiver": map[string]interface{}{},
+				},
+			},
+			rules:      []string{RuleTelemetryAPIPort},
+			components: []string{"receivers::otlp::protocols::http::endpoint"},
+		},
+		{
+			name: "telemetry api port moved",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{"telemetry": noMetrics},
+				"receivers": map[string]interface{}{
+					"otlp": map[string]interface{}{
+						"protocols": map[string]interface{}{
+							"http": map[string]interface{}{"endpoint": "localhost:4325"},
+						},
+					},
+					"telemetryapireceiver": map[string]interface{}{"port": 4326},
+				},
+			},
+		},
+		{
+			name: "telemetry metrics address",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{
+					"telemetry": map[string]interface{}{"metrics": map[string]interface{}{"address": "0.0.0.0:8888"}},
+				},
+			},
+			rules:      []string{RuleTelemetryMetrics},
+			components: []string{"service::telemetry::metrics"},
+		},
+		{
+			name: "telemetry metrics pull reader",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{
+					"telemetry": map[string]interface{}{"metrics": map[string]interface{}{
+						"readers": []interface{}{map[string]interface{}{"pull": map[string]interface{}{}}},
+					}},
+				},
+			},
+			rules:      []string{RuleTelemetryMetrics},
+			components: []string{"service::telemetry::metrics"},
+		},
+		{
+			name: "telemetry metrics default reader",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{
+					"pipelines": map[string]interface{}{
+						"traces": map[string]interface{}{"processors": []interface{}{"batch", "decouple"}},
+					},
+				},
+			},
+			rules:      []string{RuleTelemetryMetrics},
+			components: []string{"service::telemetry::metrics"},
+		},
+		{
+			name: "telemetry metrics periodic reader",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{
+					"telemetry": map[string]interface{}{"metrics": map[string]interface{}{
+						"readers": []interface{}{map[string]interface{}{"periodic": map[string]interface{}{}}},
+					}},
+				},
+			},
+		},
+		{
+			name: "telemetry metrics disabled",
+			input: map[string]interface{}{
+				"service": map[string]interface{}{
+					"telemetry": map[string]interface{}{"metrics": map[string]interface{}{"level": "none", "address": "0.0.0.0:8888"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var rules, components []string
+			for _, issue := range Lint(confmap.NewFromStringMap(tc.input)) {
+				rules = append(rules, issue.Rule)
+				components = append(components, issue.Component)
+				assert.NotEmpty(t, issue.Problem)
+				assert.NotEmpty(t, issue.Hint)
+			}
+			assert.Equal(t, tc.rules, rules)
+			assert.Equal(t, tc.components, components)
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	input := map[string]interface{}{
+		"service": map[string]interface{}{
+			"pipelines": map[string]interface{}{
+				"traces": map[string]interface{}{"processors": []interface{}{"batch"}},
+			},
+			"telemetry": noMetrics,
+		},
+	}
+
+	core, logs := observer.New(zap.WarnLevel)
+	conf := confmap.NewFromStringMap(input)
+	require.NoError(t, New(confmap.ConverterSettings{Logger: zap.New(core)}, ModeWarn).Convert(context.Background(), conf))
+	assert.Equal(t, input, conf.ToStringMap())
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, RuleBatchWithoutDecouple, logs.All()[0].ContextMap()["rule"])
+
+	err := New(confmap.ConverterSettings{}, ModeStrict).Convert(context.Background(), confmap.NewFromStringMap(input))
+	assert.ErrorContains(t, err, RuleBatchWithoutDecouple)
+}
+
+func TestParseMode(t *testing.T) {
+	for _, tc := range []struct {
+		val      string
+		expected Mode
+		wantErr  bool
+	}{
+		{val: "", expected: ModeWarn},
+		{val: "warn", expected: ModeWarn},
+		{val: "STRICT", expected: ModeStrict},
+		{val: "unknown", expected: ModeWarn, wantErr: true},
+	} {
+		t.Run(tc.val, func(t *testing.T) {
+			mode, err := ParseMode(tc.val)
+			assert.Equal(t, tc.expected, mode)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
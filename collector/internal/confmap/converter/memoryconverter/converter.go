@@ -0,0 +1,154 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The memoryconverter implements the Converter sizing the collector to the memory of the function. It adds a
+// memory_limiter processor to every pipeline when none is configured and the extension has enough memory, and scales the queue of the decouple processor
+// and the buffering of the telemetryapireceiver. Values set by the user are kept.
+package memoryconverter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"go.opentelemetry.io/collector/confmap"
+)
+
+const (
+	serviceKey    = "service"
+	pipelinesKey  = "pipelines"
+	processorsKey = "processors"
+	receiversKey  = "receivers"
+
+	memoryLimiterProcessor = "memory_limiter"
+	decoupleProcessor      = "decouple"
+	telemetryAPIReceiver   = "telemetryapireceiver"
+
+	// referenceMemoryMB is the function memory the default sizes of the components are suited to.
+	referenceMemoryMB = 512
+
+	defaultMaxQueueSize = 200
+	minMaxQueueSize     = 25
+	maxMaxQueueSize     = 2000
+
+	// The buffering of the Telemetry API is bounded by Lambda, and the receiver defaults are the minimums.
+	minTelemetryMaxItems = 1000
+	maxTelemetryMaxItems = 10000
+	minTelemetryMaxBytes = 262144
+	maxTelemetryMaxBytes = 1048576
+)
+
+// MinLimitMiB is the least extension memory the collector is limited to. Below, the limit would be reached by the
+// baseline usage of the collector alone, so that the memory_limiter would refuse all data and the garbage collector
+// would run continuously.
+const MinLimitMiB = 64
+
+// Config describes the memory available to the collector.
+type Config struct {
+	// FunctionMemoryMB is the memory of the function, shared by the function and the extension.
+	FunctionMemoryMB int
+	// ExtensionMemoryMiB is the share of the function memory the extension is limited to.
+	ExtensionMemoryMiB int
+	// MemoryLimiter tells whether the memory_limiter processor is part of the build and can be added.
+	MemoryLimiter bool
+}
+
+type converter struct {
+	cfg Config
+}
+
+// New returns a confmap.Converter sizing the collector to the memory of the function.
+func New(cfg Config) confmap.Converter {
+	return &converter{cfg: cfg}
+}
+
+func (c converter) Convert(_ context.Context, conf *confmap.Conf) error {
+	scale := float64(c.cfg.FunctionMemoryMB) / referenceMemoryMB
+	updates := make(map[string]interface{})
+
+	processors, _ := conf.Get(processorsKey).(map[string]interface{})
+	if c.cfg.MemoryLimiter && c.cfg.ExtensionMemoryMiB >= MinLimitMiB && !hasComponent(processors, memoryLimiterProcessor) {
+		c.addMemoryLimiter(conf, updates)
+	}
+
+	for name := range processors {
+		if baseName(name) != decoupleProcessor {
+			continue
+		}
+		key := fmt.Sprintf("%s::%s::max_queue_size", processorsKey, name)
+		if !conf.IsSet(key) {
+			updates[key] = scaled(defaultMaxQueueSize, scale, minMaxQueueSize, maxMaxQueueSize)
+		}
+	}
+
+	receivers, _ := conf.Get(receiversKey).(map[string]interface{})
+	for name := range receivers {
+		if baseName(name) != telemetryAPIReceiver {
+			continue
+		}
+		key := fmt.Sprintf("%s::%s::maxItems", receiversKey, name)
+		if !conf.IsSet(key) {
+			updates[key] = scaled(minTelemetryMaxItems, scale, minTelemetryMaxItems, maxTelemetryMaxItems)
+		}
+		key = fmt.Sprintf("%s::%s::maxBytes", receiversKey, name)
+		if !conf.IsSet(key) {
+			updates[key] = scaled(minTelemetryMaxBytes, scale, minTelemetryMaxBytes, maxTelemetryMaxBytes)
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := conf.Merge(confmap.NewFromStringMap(updates)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMemoryLimiter declares a memory_limiter processor limited to the memory of the extension, and puts it first in
+// every pipeline.
+func (c converter) addMemoryLimiter(conf *confmap.Conf, updates map[string]interface{}) {
+	pipelines, ok := conf.Get(fmt.Sprintf("%s::%s", serviceKey, pipelinesKey)).(map[string]interface{})
+	if !ok || len(pipelines) == 0 {
+		return
+	}
+	for name, pipelineVal := range pipelines {
+		pipeline, _ := pipelineVal.(map[string]interface{})
+		existing, _ := pipeline[processorsKey].([]interface{})
+		updates[fmt.Sprintf("%s::%s::%s::%s", serviceKey, pipelinesKey, name, processorsKey)] = append([]interface{}{memoryLimiterProcessor}, existing...)
+	}
+	updates[fmt.Sprintf("%s::%s", processorsKey, memoryLimiterProcessor)] = map[string]interface{}{
+		"check_interval":  "1s",
+		"limit_mib":       c.cfg.ExtensionMemoryMiB,
+		"spike_limit_mib": c.cfg.ExtensionMemoryMiB / 5,
+	}
+}
+
+func hasComponent(components map[string]interface{}, componentType string) bool {
+	for name := range components {
+		if baseName(name) == componentType {
+			return true
+		}
+	}
+	return false
+}
+
+func baseName(name string) string {
+	return strings.Split(name, "/")[0]
+}
+
+// scaled returns value scaled to the function memory, within [lower, upper].
+func scaled(value int, scale float64, lower, upper int) int {
+	return min(max(int(math.Round(float64(value)*scale)), lower), upper)
+}
@@ -0,0 +1,130 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memoryconverter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+func TestConvert(t *testing.T) {
+	pipeline := func(processors ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"service": map[string]interface{}{
+				"pipelines": map[string]interface{}{
+					"traces": map[string]interface{}{"processors": processors},
+					"logs":   map[string]interface{}{},
+				},
+			},
+		}
+	}
+	memoryLimiter := map[string]interface{}{"check_interval": "1s", "limit_mib": 128, "spike_limit_mib": 25}
+
+	testCases := []struct {
+		name     string
+		cfg      Config
+		input    map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "no service",
+			cfg:      Config{FunctionMemoryMB: 128, ExtensionMemoryMiB: 32, MemoryLimiter: true},
+			input:    map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:  "memory limiter added to every pipeline",
+			cfg:   Config{FunctionMemoryMB: 512, ExtensionMemoryMiB: 128, MemoryLimiter: true},
+			input: pipeline("batch"),
+			expected: map[string]interface{}{
+				"processors": map[string]interface{}{"memory_limiter": memoryLimiter},
+				"service": map[string]interface{}{
+					"pipelines": map[string]interface{}{
+						"traces": map[string]interface{}{"processors": []interface{}{"memory_limiter", "batch"}},
+						"logs":   map[string]interface{}{"processors": []interface{}{"memory_limiter"}},
+					},
+				},
+			},
+		},
+		{
+			name:     "extension memory too small for a memory limiter",
+			cfg:      Config{FunctionMemoryMB: 128, ExtensionMemoryMiB: 32, MemoryLimiter: true},
+			input:    pipeline("batch"),
+			expected: pipeline("batch"),
+		},
+		{
+			name:     "memory limiter not part of the build",
+			cfg:      Config{FunctionMemoryMB: 128, ExtensionMemoryMiB: 32},
+			input:    pipeline("batch"),
+			expected: pipeline("batch"),
+		},
+		{
+			name: "memory limiter configured by the user",
+			cfg:  Config{FunctionMemoryMB: 128, ExtensionMemoryMiB: 32, MemoryLimiter: true},
+			input: map[string]interface{}{
+				"processors": map[string]interface{}{"memory_limiter/custom": map[string]interface{}{"limit_mib": 64}},
+			},
+			expected: map[string]interface{}{
+				"processors": map[string]interface{}{"memory_limiter/custom": map[string]interface{}{"limit_mib": 64}},
+			},
+		},
+		{
+			name: "small function",
+			cfg:  Config{FunctionMemoryMB: 128, ExtensionMemoryMiB: 32},
+			input: map[string]interface{}{
+				"processors": map[string]interface{}{"decouple": nil, "decouple/custom": map[string]interface{}{"max_queue_size": 500}},
+				"receivers":  map[string]interface{}{"telemetryapireceiver": map[string]interface{}{"types": []interface{}{"platform"}}},
+			},
+			expected: map[string]interface{}{
+				"processors": map[string]interface{}{"decouple": map[string]interface{}{"max_queue_size": 50}, "decouple/custom": map[string]interface{}{"max_queue_size": 500}},
+				"receivers": map[string]interface{}{"telemetryapireceiver": map[string]interface{}{
+					"types":    []interface{}{"platform"},
+					"maxItems": 1000,
+					"maxBytes": 262144,
+				}},
+			},
+		},
+		{
+			name: "large function",
+			cfg:  Config{FunctionMemoryMB: 10240, ExtensionMemoryMiB: 2560},
+			input: map[string]interface{}{
+				"processors": map[string]interface{}{"decouple": nil},
+				"receivers":  map[string]interface{}{"telemetryapireceiver": map[string]interface{}{"maxItems": 2000}},
+			},
+			expected: map[string]interface{}{
+				"processors": map[string]interface{}{"decouple": map[string]interface{}{"max_queue_size": 2000}},
+				"receivers": map[string]interface{}{"telemetryapireceiver": map[string]interface{}{
+					"maxItems": 2000,
+					"maxBytes": 1048576,
+				}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := confmap.NewFromStringMap(tc.input)
+			if err := New(tc.cfg).Convert(context.Background(), conf); err != nil {
+				t.Errorf("unexpected error converting: %v", err)
+			}
+			if diff := cmp.Diff(confmap.NewFromStringMap(tc.expected).ToStringMap(), conf.ToStringMap()); diff != "" {
+				t.Errorf("Convert() mismatch: (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The otlponlyconverter implements the Converter running the collector with OTLP only, when the extension runs in a
+// degraded mode without the Telemetry API. The telemetryapireceiver is removed from every pipeline, and the pipelines
+// it was the only receiver of are removed, so that the collector keeps receiving the telemetry of the function.
+package otlponlyconverter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+const (
+	serviceKey   = "service"
+	pipelinesKey = "pipelines"
+	receiversKey = "receivers"
+
+	telemetryAPIReceiver = "telemetryapireceiver"
+)
+
+type converter struct {
+	degraded func() bool
+	logger   *zap.Logger
+}
+
+// New returns a confmap.Converter removing the telemetryapireceiver from the configuration when degraded returns true.
+// The configuration is left unchanged otherwise.
+func New(set confmap.ConverterSettings, degraded func() bool) confmap.Converter {
+	logger := set.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &converter{degraded: degraded, logger: logger}
+}
+
+func (c converter) Convert(_ context.Context, conf *confmap.Conf) error {
+	if !c.degraded() {
+		return nil
+	}
+	pipelines, ok := conf.Get(fmt.Sprintf("%s::%s", serviceKey, pipelinesKey)).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	updates := make(map[string]interface{})
+	var removed []string
+	for name, pipelineVal := range pipelines {
+		pipeline, _ := pipelineVal.(map[string]interface{})
+		receivers, _ := pipeline[receiversKey].([]interface{})
+		kept := slices.DeleteFunc(slices.Clone(receivers), isTelemetryAPIReceiver)
+		switch {
+		case len(kept) == len(receivers):
+		case len(kept) == 0:
+			removed = append(removed, name)
+		default:
+			updates[fmt.Sprintf("%s::%s::%s::%s", serviceKey, pipelinesKey, name, receiversKey)] = kept
+		}
+	}
+	if len(removed) == len(pipelines) {
+		// The collector cannot run without pipelines, so the receiver is kept and reports that it cannot subscribe.
+		c.logger.Warn("Every pipeline receives from the Telemetry API only, the telemetryapireceiver is kept although the Telemetry API is unavailable")
+		return nil
+	}
+
+	for _, name := range removed {
+		c.logger.Warn("Pipeline removed, as the Telemetry API it receives from is unavailable", zap.String("pipeline", name))
+		conf.Delete(fmt.Sprintf("%s::%s::%s", serviceKey, pipelinesKey, name))
+	}
+	if receivers, ok := conf.Get(receiversKey).(map[string]interface{}); ok {
+		for name := range receivers {
+			if isTelemetryAPIReceiver(name) {
+				conf.Delete(fmt.Sprintf("%s::%s", receiversKey, name))
+			}
+		}
+	}
+	if len(updates) > 0 {
+		return conf.Merge(confmap.NewFromStringMap(updates))
+	}
+	return nil
+}
+
+func isTelemetryAPIReceiver(id interface{}) bool {
+	name, _ := id.(string)
+	return strings.Split(name, "/")[0] == telemetryAPIReceiver
+}
@@ -0,0 +1,101 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlponlyconverter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+func TestConvert(t *testing.T) {
+	input := map[string]interface{}{
+		"receivers": map[string]interface{}{
+			"otlp":                      nil,
+			"telemetryapireceiver":      nil,
+			"telemetryapireceiver/logs": nil,
+		},
+		"service": map[string]interface{}{
+			"pipelines": map[string]interface{}{
+				"traces":  map[string]interface{}{"receivers": []interface{}{"otlp"}},
+				"metrics": map[string]interface{}{"receivers": []interface{}{"otlp", "telemetryapireceiver"}},
+				"logs":    map[string]interface{}{"receivers": []interface{}{"telemetryapireceiver/logs"}},
+			},
+		},
+	}
+	telemetryAPIOnly := map[string]interface{}{
+		"receivers": map[string]interface{}{"telemetryapireceiver": nil},
+		"service": map[string]interface{}{
+			"pipelines": map[string]interface{}{
+				"logs": map[string]interface{}{"receivers": []interface{}{"telemetryapireceiver"}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		degraded bool
+		input    map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "not degraded",
+			degraded: false,
+			input:    input,
+			expected: input,
+		},
+		{
+			name:     "no service",
+			degraded: true,
+			input:    map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "telemetry api receivers removed",
+			degraded: true,
+			input:    input,
+			expected: map[string]interface{}{
+				"receivers": map[string]interface{}{"otlp": nil},
+				"service": map[string]interface{}{
+					"pipelines": map[string]interface{}{
+						"traces":  map[string]interface{}{"receivers": []interface{}{"otlp"}},
+						"metrics": map[string]interface{}{"receivers": []interface{}{"otlp"}},
+					},
+				},
+			},
+		},
+		{
+			name:     "no pipeline left",
+			degraded: true,
+			input:    telemetryAPIOnly,
+			expected: telemetryAPIOnly,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := confmap.NewFromStringMap(tc.input)
+			c := New(confmap.ConverterSettings{}, func() bool { return tc.degraded })
+			if err := c.Convert(context.Background(), conf); err != nil {
+				t.Errorf("unexpected error converting: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, conf.ToStringMap()); diff != "" {
+				t.Errorf("Convert() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lambdaprovider implements the confmap.Provider of the "lambda" scheme, which exposes the context of the
+// Lambda function to the configuration, for example ${lambda:function_name} or ${lambda:account_id}.
+package lambdaprovider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+const schemeName = "lambda"
+
+// Keys supported by the provider.
+const (
+	FunctionName       = "function_name"
+	FunctionVersion    = "function_version"
+	FunctionMemorySize = "function_memory_size"
+	AccountID          = "account_id"
+	Region             = "region"
+	Architecture       = "architecture"
+	InitializationType = "initialization_type"
+	LogGroupName       = "log_group_name"
+	LogStreamName      = "log_stream_name"
+	ExtensionVersion   = "extension_version"
+)
+
+// envKeys are the keys read from the environment of the function. Unless set by the user, the account ID is the one
+// returned to the extension on registration, see lambdalifecycle.AccountID.
+var envKeys = map[string]string{
+	FunctionName:       "AWS_LAMBDA_FUNCTION_NAME",
+	FunctionVersion:    "AWS_LAMBDA_FUNCTION_VERSION",
+	FunctionMemorySize: "AWS_LAMBDA_FUNCTION_MEMORY_SIZE",
+	AccountID:          "AWS_ACCOUNT_ID",
+	Region:             "AWS_REGION",
+	InitializationType: "AWS_LAMBDA_INITIALIZATION_TYPE",
+	LogGroupName:       "AWS_LAMBDA_LOG_GROUP_NAME",
+	LogStreamName:      "AWS_LAMBDA_LOG_STREAM_NAME",
+}
+
+// architectures maps the Go architectures to the names Lambda uses.
+var architectures = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "arm64",
+}
+
+type provider struct {
+	logger  *zap.Logger
+	version string
+}
+
+// NewFactory returns a factory for a confmap.Provider returning the context of the Lambda function, with version as
+// the extension version.
+//
+// This Provider supports the "lambda" scheme, and is called with a key, for example `lambda:function_name`. A default
+// value for a key that is not available can be provided after the :- suffix, for example `lambda:account_id:-unknown`.
+func NewFactory(version string) confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(ps confmap.ProviderSettings) confmap.Provider {
+		logger := ps.Logger
+		if logger == nil {
+			logger = zap.NewNop()
+		}
+		return &provider{logger: logger, version: version}
+	})
+}
+
+func (p *provider) Retrieve(_ context.Context, uri string, _ confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	if !strings.HasPrefix(uri, schemeName+":") {
+		return nil, fmt.Errorf("%q uri is not supported by %q provider", uri, schemeName)
+	}
+	key, defaultVal, hasDefault := strings.Cut(uri[len(schemeName)+1:], ":-")
+
+	val, ok, err := p.value(key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if hasDefault {
+			val = defaultVal
+		} else {
+			p.logger.Warn("Configuration references an unavailable Lambda value", zap.String("key", key))
+		}
+	}
+	return confmap.NewRetrievedFromYAML([]byte(val))
+}
+
+// value returns the value of key, and whether it is available.
+func (p *provider) value(key string) (string, bool, error) {
+	switch key {
+	case Architecture:
+		arch, ok := architectures[runtime.GOARCH]
+		return arch, ok, nil
+	case ExtensionVersion:
+		return p.version, p.version != "", nil
+	}
+	env, ok := envKeys[key]
+	if !ok {
+		return "", false, fmt.Errorf("unknown Lambda key %q, expected one of %s", key, strings.Join(Keys(), ", "))
+	}
+	val, ok := os.LookupEnv(env)
+	if (!ok || val == "") && key == AccountID {
+		val = lambdalifecycle.AccountID()
+		return val, val != "", nil
+	}
+	return val, ok && val != "", nil
+}
+
+// Keys returns the keys supported by the provider, sorted.
+func Keys() []string {
+	keys := []string{Architecture, ExtensionVersion}
+	for key := range envKeys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (*provider) Scheme() string {
+	return schemeName
+}
+
+func (*provider) Shutdown(context.Context) error {
+	return nil
+}
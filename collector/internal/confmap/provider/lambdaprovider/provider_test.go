@@ -0,0 +1,116 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lambdaprovider
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/confmaptest"
+	"go.opentelemetry.io/collector/confmap/provider/yamlprovider"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+func TestRetrieve(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "my-function")
+	t.Setenv("AWS_ACCOUNT_ID", "012345678901")
+	t.Setenv("AWS_LAMBDA_INITIALIZATION_TYPE", "")
+
+	for _, tc := range []struct {
+		uri      string
+		expected string
+		err      string
+	}{
+		{uri: "lambda:function_name", expected: "my-function"},
+		{uri: "lambda:account_id", expected: "012345678901"},
+		{uri: "lambda:extension_version", expected: "1.2.3"},
+		{uri: "lambda:initialization_type:-on-demand", expected: "on-demand"},
+		{uri: "lambda:function_name:-default", expected: "my-function"},
+		{uri: "lambda:initialization_type", expected: ""},
+		{uri: "lambda:alias", err: `unknown Lambda key "alias", expected one of account_id, architecture,`},
+		{uri: "env:HOME", err: `"env:HOME" uri is not supported by "lambda" provider`},
+	} {
+		t.Run(tc.uri, func(t *testing.T) {
+			p := NewFactory("1.2.3").Create(confmaptest.NewNopProviderSettings())
+			retrieved, err := p.Retrieve(context.Background(), tc.uri, nil)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			val, err := retrieved.AsString()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, val)
+			require.NoError(t, p.Shutdown(context.Background()))
+		})
+	}
+}
+
+// accountIDNotifier knows the account ID returned on registration.
+type accountIDNotifier struct{}
+
+func (accountIDNotifier) AddListener(lambdalifecycle.Listener) {}
+func (accountIDNotifier) AccountID() string                    { return "123456789012" }
+
+func TestRetrieveRegisteredAccountID(t *testing.T) {
+	previous := lambdalifecycle.GetNotifier()
+	lambdalifecycle.SetNotifier(accountIDNotifier{})
+	defer lambdalifecycle.SetNotifier(previous)
+	p := NewFactory("").Create(confmaptest.NewNopProviderSettings())
+
+	t.Setenv("AWS_ACCOUNT_ID", "")
+	require.NoError(t, os.Unsetenv("AWS_ACCOUNT_ID"))
+	retrieved, err := p.Retrieve(context.Background(), "lambda:account_id", nil)
+	require.NoError(t, err)
+	val, err := retrieved.AsString()
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012", val)
+
+	// An account ID set by the user is kept.
+	t.Setenv("AWS_ACCOUNT_ID", "012345678901")
+	retrieved, err = p.Retrieve(context.Background(), "lambda:account_id", nil)
+	require.NoError(t, err)
+	val, err = retrieved.AsString()
+	require.NoError(t, err)
+	assert.Equal(t, "012345678901", val)
+}
+
+func TestArchitecture(t *testing.T) {
+	p := NewFactory("").Create(confmaptest.NewNopProviderSettings())
+	retrieved, err := p.Retrieve(context.Background(), "lambda:architecture", nil)
+	require.NoError(t, err)
+	val, err := retrieved.AsString()
+	require.NoError(t, err)
+	assert.Equal(t, architectures[runtime.GOARCH], val)
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "my-function")
+	t.Setenv("AWS_ACCOUNT_ID", "012345678901")
+	resolver, err := confmap.NewResolver(confmap.ResolverSettings{
+		URIs:              []string{"yaml:service_name: ${lambda:function_name}-${lambda:account_id}"},
+		ProviderFactories: []confmap.ProviderFactory{NewFactory("1.2.3"), yamlprovider.NewFactory()},
+	})
+	require.NoError(t, err)
+	conf, err := resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "my-function-012345678901", conf.Get("service_name"))
+}
@@ -0,0 +1,189 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logzioprovider implements the confmap.Provider of the "logzio" scheme, which generates a configuration
+// shipping to Logz.io from the LOGZIO_* environment variables, so that no configuration file is needed.
+package logzioprovider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/confmap"
+)
+
+const schemeName = "logzio"
+
+// URI is the URI of the generated configuration.
+const URI = schemeName + ":config"
+
+// Environment variables the configuration is generated from.
+const (
+	// RegionEnv is the Logz.io region of the account, "us" by default.
+	RegionEnv       = "LOGZIO_REGION"
+	TracesTokenEnv  = "LOGZIO_TRACES_TOKEN"
+	MetricsTokenEnv = "LOGZIO_METRICS_TOKEN"
+	LogsTokenEnv    = "LOGZIO_LOGS_TOKEN"
+)
+
+const (
+	defaultRegion    = "us"
+	userAgentPrefix  = "logzio-opentelemetry-layer-"
+	metricsPort      = 8053
+	telemetryAPIName = "telemetryapireceiver"
+)
+
+// regions are the Logz.io regions with a listener, see the logzio exporter.
+var regions = []string{"au", "ca", "eu", "uk", defaultRegion}
+
+// TokenEnvs are the environment variables holding the tokens of the signals.
+var TokenEnvs = []string{TracesTokenEnv, MetricsTokenEnv, LogsTokenEnv}
+
+// dropArrayTags are the attributes holding arrays, which Jaeger (classic) rejects as tags.
+var dropArrayTags = []string{"process.command_args", "aws.log.group.names", "process.tags"}
+
+type provider struct{}
+
+// NewFactory returns a factory for a confmap.Provider generating a Logz.io configuration from the environment.
+//
+// This Provider supports the "logzio" scheme, and is called with `logzio:config`. The configuration has a pipeline for
+// each signal whose token is set: traces for LOGZIO_TRACES_TOKEN, metrics for LOGZIO_METRICS_TOKEN and logs for
+// LOGZIO_LOGS_TOKEN, shipping to the listener of LOGZIO_REGION.
+func NewFactory() confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(confmap.ProviderSettings) confmap.Provider {
+		return &provider{}
+	})
+}
+
+func (*provider) Retrieve(_ context.Context, uri string, _ confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	if uri != URI {
+		return nil, fmt.Errorf("%q uri is not supported by %q provider, expected %q", uri, schemeName, URI)
+	}
+	conf, err := Config()
+	if err != nil {
+		return nil, err
+	}
+	return confmap.NewRetrieved(conf)
+}
+
+func (*provider) Scheme() string {
+	return schemeName
+}
+
+func (*provider) Shutdown(context.Context) error {
+	return nil
+}
+
+// Enabled returns whether a Logz.io token is set, so that a configuration can be generated.
+func Enabled() bool {
+	for _, env := range TokenEnvs {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Config returns the configuration generated from the environment. The tokens are referenced as environment
+// variables rather than copied, as in the configuration files of the layer.
+func Config() (map[string]any, error) {
+	region := strings.ToLower(strings.TrimSpace(os.Getenv(RegionEnv)))
+	if region == "" {
+		region = defaultRegion
+	}
+	if !slices.Contains(regions, region) {
+		return nil, fmt.Errorf("unknown Logz.io region %q in %s, expected one of %s", region, RegionEnv, strings.Join(regions, ", "))
+	}
+
+	exporters := map[string]any{}
+	pipelines := map[string]any{}
+	receivers := []any{"otlp", telemetryAPIName}
+	if os.Getenv(TracesTokenEnv) != "" {
+		exporters["logzio/traces"] = logzioExporter(TracesTokenEnv, region, "traces")
+		pipelines["traces"] = map[string]any{
+			"receivers":  receivers,
+			"processors": []any{"resource/drop_array_tags", "attributes/drop_array_tags", "batch"},
+			"exporters":  []any{"logzio/traces"},
+		}
+	}
+	if os.Getenv(MetricsTokenEnv) != "" {
+		exporters["prometheusremotewrite"] = map[string]any{
+			"endpoint": fmt.Sprintf("https://%s:%d", ListenerHost(region), metricsPort),
+			"headers": map[string]any{
+				"Authorization": fmt.Sprintf("Bearer ${env:%s}", MetricsTokenEnv),
+				"user-agent":    userAgentPrefix + "metrics",
+			},
+			"target_info": map[string]any{"enabled": false},
+		}
+		pipelines["metrics"] = map[string]any{
+			"receivers":  receivers,
+			"processors": []any{"batch"},
+			"exporters":  []any{"prometheusremotewrite"},
+		}
+	}
+	if os.Getenv(LogsTokenEnv) != "" {
+		exporters["logzio/logs"] = logzioExporter(LogsTokenEnv, region, "logs")
+		// Logs of the function are collected from the Telemetry API, as in the configuration files of the layer.
+		pipelines["logs"] = map[string]any{
+			"receivers":  []any{telemetryAPIName},
+			"processors": []any{"batch"},
+			"exporters":  []any{"logzio/logs"},
+		}
+	}
+	if len(pipelines) == 0 {
+		return nil, fmt.Errorf("no Logz.io token is set, expected at least one of %s", strings.Join(TokenEnvs, ", "))
+	}
+
+	var deleteActions []any
+	for _, key := range dropArrayTags {
+		deleteActions = append(deleteActions, map[string]any{"key": key, "action": "delete"})
+	}
+	return map[string]any{
+		"receivers": map[string]any{
+			"otlp": map[string]any{
+				"protocols": map[string]any{
+					"grpc": map[string]any{"endpoint": "localhost:4317"},
+					"http": map[string]any{"endpoint": "localhost:4318"},
+				},
+			},
+			telemetryAPIName: map[string]any{"types": []any{"platform", "function", "extension"}},
+		},
+		"processors": map[string]any{
+			"batch":                      nil,
+			"attributes/drop_array_tags": map[string]any{"actions": deleteActions},
+			"resource/drop_array_tags":   map[string]any{"attributes": deleteActions},
+		},
+		"exporters": exporters,
+		"service":   map[string]any{"pipelines": pipelines},
+	}, nil
+}
+
+// ListenerHost returns the host of the Logz.io listener of region.
+func ListenerHost(region string) string {
+	if region == defaultRegion {
+		return "listener.logz.io"
+	}
+	return fmt.Sprintf("listener-%s.logz.io", region)
+}
+
+func logzioExporter(tokenEnv, region, signal string) map[string]any {
+	return map[string]any{
+		"account_token": fmt.Sprintf("${env:%s}", tokenEnv),
+		"region":        region,
+		"headers":       map[string]any{"user-agent": userAgentPrefix + signal},
+	}
+}
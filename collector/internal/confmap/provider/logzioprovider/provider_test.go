@@ -0,0 +1,124 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logzioprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/provider/envprovider"
+)
+
+func resolve(t *testing.T) (*confmap.Conf, error) {
+	resolver, err := confmap.NewResolver(confmap.ResolverSettings{
+		URIs:              []string{URI},
+		ProviderFactories: []confmap.ProviderFactory{NewFactory(), envprovider.NewFactory()},
+	})
+	require.NoError(t, err)
+	return resolver.Resolve(context.Background())
+}
+
+func TestConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		env       map[string]string
+		pipelines []string
+		exporters []string
+	}{
+		{
+			name:      "all signals",
+			env:       map[string]string{TracesTokenEnv: "traces", MetricsTokenEnv: "metrics", LogsTokenEnv: "logs"},
+			pipelines: []string{"logs", "metrics", "traces"},
+			exporters: []string{"logzio/logs", "logzio/traces", "prometheusremotewrite"},
+		},
+		{
+			name:      "traces only",
+			env:       map[string]string{TracesTokenEnv: "traces", LogsTokenEnv: ""},
+			pipelines: []string{"traces"},
+			exporters: []string{"logzio/traces"},
+		},
+		{
+			name:      "metrics and logs",
+			env:       map[string]string{MetricsTokenEnv: "metrics", LogsTokenEnv: "logs"},
+			pipelines: []string{"logs", "metrics"},
+			exporters: []string{"logzio/logs", "prometheusremotewrite"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, env := range TokenEnvs {
+				t.Setenv(env, tc.env[env])
+			}
+			require.True(t, Enabled())
+			conf, err := resolve(t)
+			require.NoError(t, err)
+
+			var names []string
+			for name := range conf.Get("service::pipelines").(map[string]any) {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tc.pipelines, names)
+			names = nil
+			for name := range conf.Get("exporters").(map[string]any) {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tc.exporters, names)
+			if conf.IsSet("service::pipelines::logs") {
+				assert.Equal(t, []any{"telemetryapireceiver"}, conf.Get("service::pipelines::logs::receivers"))
+			}
+		})
+	}
+}
+
+func TestConfigExporters(t *testing.T) {
+	t.Setenv(TracesTokenEnv, "traces-token")
+	t.Setenv(MetricsTokenEnv, "metrics-token")
+	t.Setenv(LogsTokenEnv, "")
+	t.Setenv(RegionEnv, "EU")
+
+	conf, err := resolve(t)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"account_token": "traces-token",
+		"region":        "eu",
+		"headers":       map[string]any{"user-agent": "logzio-opentelemetry-layer-traces"},
+	}, conf.Get("exporters::logzio/traces"))
+	assert.Equal(t, "https://listener-eu.logz.io:8053", conf.Get("exporters::prometheusremotewrite::endpoint"))
+	assert.Equal(t, "Bearer metrics-token", conf.Get("exporters::prometheusremotewrite::headers::Authorization"))
+	assert.Equal(t, "logzio-opentelemetry-layer-metrics", conf.Get("exporters::prometheusremotewrite::headers::user-agent"))
+}
+
+func TestUnknownRegion(t *testing.T) {
+	t.Setenv(TracesTokenEnv, "traces-token")
+	t.Setenv(RegionEnv, "mars")
+	_, err := resolve(t)
+	require.ErrorContains(t, err, `unknown Logz.io region "mars"`)
+}
+
+func TestNoToken(t *testing.T) {
+	for _, env := range TokenEnvs {
+		t.Setenv(env, "")
+	}
+	require.False(t, Enabled())
+	_, err := resolve(t)
+	require.ErrorContains(t, err, "no Logz.io token is set")
+}
+
+func TestListenerHost(t *testing.T) {
+	assert.Equal(t, "listener.logz.io", ListenerHost("us"))
+	assert.Equal(t, "listener-uk.logz.io", ListenerHost("uk"))
+}
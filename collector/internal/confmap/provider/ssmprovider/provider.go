@@ -0,0 +1,220 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ssmprovider implements the confmap.Provider of the "ssm" scheme, which reads parameters from the AWS Systems
+// Manager Parameter Store, for example ${ssm:/otel/logzio/traces_token}.
+package ssmprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+const schemeName = "ssm"
+
+// client is the Parameter Store client shared by the providers of a factory.
+type client struct {
+	mu  sync.Mutex
+	ssm *ssm.Client
+}
+
+// parameters are the parameters read by a provider. A provider is created for each resolution of the configuration,
+// so that parameters referenced several times are read once, while resolving the configuration again, such as on
+// reload, reads them again and picks up rotated values.
+type parameters struct {
+	mu     sync.Mutex
+	client *client
+	// values are the parameter values by name, and the parameter maps by path.
+	values map[string]any
+}
+
+type provider struct {
+	params *parameters
+	logger *zap.Logger
+}
+
+// NewFactory returns a factory for a confmap.Provider reading parameters from the AWS Systems Manager Parameter Store.
+// SecureString parameters are decrypted. Parameters are cached while the configuration is resolved.
+//
+// This Provider supports the "ssm" scheme, and is called with the name or ARN of a parameter, for example
+// `ssm:/otel/token`. A key of a parameter holding a JSON object is selected after #, for example `ssm:/otel/tokens#traces`.
+// A path ending with /, for example `ssm:/otel/`, returns the map of all the parameters under it. A default value for a
+// parameter that does not exist can be provided after the :- suffix, for example `ssm:/otel/token:-default`.
+func NewFactory() confmap.ProviderFactory {
+	c := &client{}
+	return confmap.NewProviderFactory(func(ps confmap.ProviderSettings) confmap.Provider {
+		logger := ps.Logger
+		if logger == nil {
+			logger = zap.NewNop()
+		}
+		return &provider{params: &parameters{client: c, values: map[string]any{}}, logger: logger}
+	})
+}
+
+func (p *provider) Retrieve(ctx context.Context, uri string, _ confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	if !strings.HasPrefix(uri, schemeName+":") {
+		return nil, fmt.Errorf("%q uri is not supported by %q provider", uri, schemeName)
+	}
+	selector, defaultVal, hasDefault := strings.Cut(uri[len(schemeName)+1:], ":-")
+	name, jsonKey, hasJSONKey := strings.Cut(selector, "#")
+	if name == "" {
+		return nil, fmt.Errorf("%q uri has no parameter name", uri)
+	}
+	isPath := strings.HasSuffix(name, "/")
+	if isPath && hasJSONKey {
+		return nil, fmt.Errorf("%q uri selects a key of a path, which is only supported for parameters", uri)
+	}
+
+	val, err := p.params.get(ctx, name, isPath)
+	var notFound *types.ParameterNotFound
+	if errors.As(err, &notFound) && hasDefault {
+		p.logger.Warn("SSM parameter not found, falling back to the default value", zap.String("name", name))
+		return confmap.NewRetrieved(defaultVal)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SSM parameter %q: %w", name, err)
+	}
+	if !hasJSONKey {
+		return confmap.NewRetrieved(val)
+	}
+
+	var fields map[string]any
+	if err = json.Unmarshal([]byte(val.(string)), &fields); err != nil {
+		return nil, fmt.Errorf("SSM parameter %q is not a JSON object: %w", name, err)
+	}
+	field, ok := fields[jsonKey]
+	if !ok {
+		if hasDefault {
+			p.logger.Warn("Key not found in SSM parameter, falling back to the default value", zap.String("name", name), zap.String("key", jsonKey))
+			return confmap.NewRetrieved(defaultVal)
+		}
+		return nil, fmt.Errorf("key %q not found in SSM parameter %q", jsonKey, name)
+	}
+	return confmap.NewRetrieved(field)
+}
+
+func (*provider) Scheme() string {
+	return schemeName
+}
+
+func (*provider) Shutdown(context.Context) error {
+	return nil
+}
+
+// get returns the value of the parameter name, or the map of the parameters under the path name.
+func (p *parameters) get(ctx context.Context, name string, isPath bool) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if val, ok := p.values[name]; ok {
+		return val, nil
+	}
+
+	client, err := p.client.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var val any
+	if isPath {
+		val, err = getPath(ctx, client, name)
+	} else {
+		val, err = getParameter(ctx, client, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.values[name] = val
+	return val, nil
+}
+
+// get returns the client, which is created on the first parameter, so that configurations without ssm URIs do not
+// load the AWS config.
+func (c *client) get(ctx context.Context) (*ssm.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ssm == nil {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the AWS SDK configuration: %w", err)
+		}
+		c.ssm = ssm.NewFromConfig(cfg)
+	}
+	return c.ssm, nil
+}
+
+func getParameter(ctx context.Context, client *ssm.Client, name string) (string, error) {
+	out, err := client.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
+
+// getPath returns the parameters under path, recursively, as nested maps following the hierarchy of their names.
+func getPath(ctx context.Context, client *ssm.Client, path string) (map[string]any, error) {
+	params := map[string]any{}
+	parent := strings.TrimSuffix(path, "/")
+	if parent == "" {
+		parent = "/"
+	}
+	paginator := ssm.NewGetParametersByPathPaginator(client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(parent),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range out.Parameters {
+			name := aws.ToString(param.Name)
+			if err = setNested(params, strings.Split(strings.TrimPrefix(name, path), "/"), aws.ToString(param.Value)); err != nil {
+				return nil, fmt.Errorf("SSM parameter %q: %w", name, err)
+			}
+		}
+	}
+	return params, nil
+}
+
+func setNested(m map[string]any, keys []string, val string) error {
+	if len(keys) == 1 {
+		if _, ok := m[keys[0]]; ok {
+			return fmt.Errorf("%q is both a parameter and a path", keys[0])
+		}
+		m[keys[0]] = val
+		return nil
+	}
+	child, ok := m[keys[0]]
+	if !ok {
+		child = map[string]any{}
+		m[keys[0]] = child
+	}
+	childMap, ok := child.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%q is both a parameter and a path", keys[0])
+	}
+	return setNested(childMap, keys[1:], val)
+}
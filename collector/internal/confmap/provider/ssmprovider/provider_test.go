@@ -0,0 +1,202 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/confmaptest"
+	"go.opentelemetry.io/collector/confmap/provider/yamlprovider"
+)
+
+// parameterStore is a local stand-in for the SSM endpoint, serving GetParameter and GetParametersByPath.
+type parameterStore struct {
+	mu       sync.Mutex
+	params   map[string]string
+	requests atomic.Int32
+}
+
+// set changes the value of the parameter name, as when it is rotated.
+func (s *parameterStore) set(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.params[name] = value
+}
+
+func (s *parameterStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var input struct {
+		Name           string
+		Path           string
+		WithDecryption bool
+		NextToken      string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	parameter := func(name string) map[string]any {
+		value := s.params[name]
+		if !input.WithDecryption {
+			value = "encrypted"
+		}
+		return map[string]any{"Name": name, "Value": value, "Type": "SecureString"}
+	}
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "AmazonSSM.GetParameter":
+		if _, ok := s.params[input.Name]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"__type": "ParameterNotFound", "message": input.Name})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"Parameter": parameter(input.Name)})
+	case "AmazonSSM.GetParametersByPath":
+		// Each parameter is returned in its own page, to exercise the pagination.
+		var names []string
+		for name := range s.params {
+			if strings.HasPrefix(name, input.Path+"/") {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		var page []any
+		next := ""
+		for i, name := range names {
+			if name > input.NextToken {
+				page = append(page, parameter(name))
+				if i < len(names)-1 {
+					next = name
+				}
+				break
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"Parameters": page, "NextToken": next})
+	default:
+		http.Error(w, "unsupported operation", http.StatusBadRequest)
+	}
+}
+
+func newParameterStore(t *testing.T, params map[string]string) *parameterStore {
+	store := &parameterStore{params: params}
+	server := httptest.NewServer(store)
+	t.Cleanup(server.Close)
+	t.Setenv("AWS_ENDPOINT_URL_SSM", server.URL)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	return store
+}
+
+func TestRetrieve(t *testing.T) {
+	newParameterStore(t, map[string]string{
+		"/otel/token":  "secret",
+		"/otel/tokens": `{"traces": "traces-secret", "logs": "logs-secret"}`,
+	})
+
+	for _, tc := range []struct {
+		uri      string
+		expected any
+		err      string
+	}{
+		{uri: "ssm:/otel/token", expected: "secret"},
+		{uri: "ssm:/otel/tokens#traces", expected: "traces-secret"},
+		{uri: "ssm:/otel/tokens#metrics:-none", expected: "none"},
+		{uri: "ssm:/otel/missing:-default", expected: "default"},
+		{uri: "ssm:/otel/tokens#metrics", err: `key "metrics" not found in SSM parameter "/otel/tokens"`},
+		{uri: "ssm:/otel/token#traces", err: `SSM parameter "/otel/token" is not a JSON object`},
+		{uri: "ssm:/otel/missing", err: `failed to get SSM parameter "/otel/missing"`},
+		{uri: "ssm:/otel/#traces", err: "only supported for parameters"},
+		{uri: "ssm:", err: "has no parameter name"},
+		{uri: "env:HOME", err: `"env:HOME" uri is not supported by "ssm" provider`},
+	} {
+		t.Run(tc.uri, func(t *testing.T) {
+			p := NewFactory().Create(confmaptest.NewNopProviderSettings())
+			retrieved, err := p.Retrieve(context.Background(), tc.uri, nil)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			val, err := retrieved.AsRaw()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, val)
+		})
+	}
+}
+
+func TestRetrievePath(t *testing.T) {
+	newParameterStore(t, map[string]string{
+		"/otel/logzio/traces_token": "traces-secret",
+		"/otel/logzio/logs_token":   "logs-secret",
+		"/otel/region":              "eu",
+		"/other/token":              "other",
+	})
+
+	p := NewFactory().Create(confmaptest.NewNopProviderSettings())
+	retrieved, err := p.Retrieve(context.Background(), "ssm:/otel/", nil)
+	require.NoError(t, err)
+	val, err := retrieved.AsRaw()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"logzio": map[string]any{"traces_token": "traces-secret", "logs_token": "logs-secret"},
+		"region": "eu",
+	}, val)
+}
+
+func TestCache(t *testing.T) {
+	store := newParameterStore(t, map[string]string{"/otel/tokens": `{"traces": "traces-secret", "logs": "logs-secret"}`})
+
+	factory := NewFactory()
+	resolve := func() *confmap.Conf {
+		resolver, err := confmap.NewResolver(confmap.ResolverSettings{
+			URIs:              []string{"yaml:traces: ${ssm:/otel/tokens#traces}\nlogs: ${ssm:/otel/tokens#logs}"},
+			ProviderFactories: []confmap.ProviderFactory{factory, yamlprovider.NewFactory()},
+		})
+		require.NoError(t, err)
+		conf, err := resolver.Resolve(context.Background())
+		require.NoError(t, err)
+		return conf
+	}
+
+	conf := resolve()
+	assert.Equal(t, "traces-secret", conf.Get("traces"))
+	assert.Equal(t, "logs-secret", conf.Get("logs"))
+	// The parameter is read once while the configuration is resolved, although it is referenced twice.
+	assert.Equal(t, int32(1), store.requests.Load())
+
+	// Resolving the configuration again, as on reload, reads the rotated value.
+	store.set("/otel/tokens", `{"traces": "rotated-secret", "logs": "logs-secret"}`)
+	conf = resolve()
+	assert.Equal(t, "rotated-secret", conf.Get("traces"))
+	assert.Equal(t, int32(2), store.requests.Load())
+}
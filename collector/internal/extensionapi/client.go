@@ -30,16 +30,19 @@ type RegisterResponse struct {
 	FunctionName    string `json:"functionName"`
 	FunctionVersion string `json:"functionVersion"`
 	Handler         string `json:"handler"`
-	ExtensionID     string
+	// AccountID is the AWS account ID of the function. It is only returned when the accountId feature is accepted.
+	AccountID   string `json:"accountId"`
+	ExtensionID string
 }
 
 // NextEventResponse is the response for /event/next
 type NextEventResponse struct {
-	EventType          EventType `json:"eventType"`
-	DeadlineMs         int64     `json:"deadlineMs"`
-	RequestID          string    `json:"requestId"`
-	InvokedFunctionArn string    `json:"invokedFunctionArn"`
-	Tracing            Tracing   `json:"tracing"`
+	EventType          EventType      `json:"eventType"`
+	DeadlineMs         int64          `json:"deadlineMs"`
+	RequestID          string         `json:"requestId"`
+	InvokedFunctionArn string         `json:"invokedFunctionArn"`
+	Tracing            Tracing        `json:"tracing"`
+	ShutdownReason     ShutdownReason `json:"shutdownReason"`
 }
 
 // Tracing is part of the response for /event/next
@@ -64,10 +67,28 @@ const (
 	Shutdown EventType = "SHUTDOWN"
 )
 
+// ShutdownReason represents the reason of a SHUTDOWN event received from /event/next
+type ShutdownReason string
+
+const (
+	// Spindown is a regular shutdown of the environment
+	Spindown ShutdownReason = "spindown"
+
+	// Timeout is a shutdown after the function or an extension timed out
+	Timeout ShutdownReason = "timeout"
+
+	// Failure is a shutdown after an error, such as an out-of-memory event
+	Failure ShutdownReason = "failure"
+)
+
 const (
 	extensionNameHeader      = "Lambda-Extension-Name"
 	extensionIdentiferHeader = "Lambda-Extension-Identifier"
 	extensionErrorType       = "Lambda-Extension-Function-Error-Type"
+	extensionAcceptFeature   = "Lambda-Extension-Accept-Feature"
+
+	// accountIDFeature asks the Extensions API to return the account ID on registration.
+	accountIDFeature = "accountId"
 )
 
 // Client is a simple client for the Lambda Extensions API.
@@ -104,6 +125,7 @@ func (e *Client) Register(ctx context.Context, filename string) (*RegisterRespon
 		return nil, err
 	}
 	req.Header.Set(extensionNameHeader, filename)
+	req.Header.Set(extensionAcceptFeature, accountIDFeature)
 
 	var registerResp RegisterResponse
 	resp, err := e.doRequest(req, &registerResp)
@@ -111,7 +133,7 @@ func (e *Client) Register(ctx context.Context, filename string) (*RegisterRespon
 		return nil, err
 	}
 	e.extensionID = resp.Header.Get(extensionIdentiferHeader)
-	e.logger.Debug("Registered extension", zap.String("ID", e.extensionID))
+	e.logger.Debug("Registered extension", zap.String("ID", e.extensionID), zap.String("accountID", registerResp.AccountID))
 
 	registerResp.ExtensionID = e.extensionID
 	return &registerResp, nil
@@ -137,36 +159,32 @@ func (e *Client) NextEvent(ctx context.Context) (*NextEventResponse, error) {
 
 // InitError reports an initialization error to the platform.
 // Call it when you registered but failed to initialize.
-func (e *Client) InitError(ctx context.Context, errorType string) (*StatusResponse, error) {
-	const action = "/init/error"
-	url := e.baseURL + action
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set(extensionIdentiferHeader, e.extensionID)
-	req.Header.Set(extensionErrorType, errorType)
-
-	var statusResp StatusResponse
-	if _, err := e.doRequest(req, &statusResp); err != nil {
-		return nil, err
-	}
-	return &statusResp, nil
+// The error is categorized by its ErrorType, see NewError.
+func (e *Client) InitError(ctx context.Context, extErr error) (*StatusResponse, error) {
+	return e.reportError(ctx, "/init/error", extErr)
 }
 
 // ExitError reports an error to the platform before exiting.
 // Call it when you encounter an unexpected failure.
-func (e *Client) ExitError(ctx context.Context, errorType string) (*StatusResponse, error) {
-	const action = "/exit/error"
+// The error is categorized by its ErrorType, see NewError.
+func (e *Client) ExitError(ctx context.Context, extErr error) (*StatusResponse, error) {
+	return e.reportError(ctx, "/exit/error", extErr)
+}
+
+func (e *Client) reportError(ctx context.Context, action string, extErr error) (*StatusResponse, error) {
 	url := e.baseURL + action
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	errorType, body := newErrorRequest(extErr)
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set(extensionIdentiferHeader, e.extensionID)
-	req.Header.Set(extensionErrorType, errorType)
+	req.Header.Set(extensionErrorType, string(errorType))
 
 	var statusResp StatusResponse
 	if _, err := e.doRequest(req, &statusResp); err != nil {
@@ -0,0 +1,103 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extensionapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRegister(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/2020-01-01/extension/register", r.URL.Path)
+		require.Equal(t, "collector", r.Header.Get(extensionNameHeader))
+		require.Equal(t, "accountId", r.Header.Get(extensionAcceptFeature))
+		w.Header().Set(extensionIdentiferHeader, "extension-id")
+		_, _ = w.Write([]byte(`{"functionName":"my-function","functionVersion":"$LATEST","handler":"index.handler","accountId":"123456789012"}`))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	resp, err := NewClient(zaptest.NewLogger(t), u.Host).Register(context.Background(), "collector")
+	require.NoError(t, err)
+	require.Equal(t, "extension-id", resp.ExtensionID)
+	require.Equal(t, "my-function", resp.FunctionName)
+	require.Equal(t, "123456789012", resp.AccountID)
+}
+
+func TestReportError(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		err          error
+		expectedType ErrorType
+		expectStack  bool
+	}{
+		{
+			desc:         "categorized error",
+			err:          NewError(SubscribeFailed, errors.New("connection refused")),
+			expectedType: SubscribeFailed,
+			expectStack:  true,
+		},
+		{
+			desc:         "wrapped categorized error",
+			err:          fmt.Errorf("init failed: %w", NewError(ConfigInvalid, errors.New("bad yaml"))),
+			expectedType: ConfigInvalid,
+			expectStack:  true,
+		},
+		{
+			desc:         "uncategorized error",
+			err:          errors.New("boom"),
+			expectedType: Unknown,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var header string
+			var body errorRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/2020-01-01/extension/init/error", r.URL.Path)
+				header = r.Header.Get(extensionErrorType)
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				_, _ = w.Write([]byte(`{"status":"OK"}`))
+			}))
+			defer server.Close()
+			u, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			client := NewClient(zaptest.NewLogger(t), u.Host)
+			resp, err := client.InitError(context.Background(), tc.err)
+			require.NoError(t, err)
+			require.Equal(t, "OK", resp.Status)
+			require.Equal(t, string(tc.expectedType), header)
+			require.Equal(t, string(tc.expectedType), body.ErrorType)
+			require.Equal(t, tc.err.Error(), body.ErrorMessage)
+			if tc.expectStack {
+				require.Contains(t, body.StackTrace[0], "extensionapi.TestReportError")
+			} else {
+				require.Empty(t, body.StackTrace)
+			}
+		})
+	}
+}
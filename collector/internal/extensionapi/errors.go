@@ -0,0 +1,99 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extensionapi
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// ErrorType categorizes the errors reported to /init/error and /exit/error, in the "Category.Reason" form expected by
+// Lambda. It is reported in the platform.initRuntimeDone and platform.extension records.
+type ErrorType string
+
+const (
+	// ConfigInvalid is reported when the collector configuration cannot be resolved or is invalid.
+	ConfigInvalid ErrorType = "Extension.ConfigInvalid"
+	// ListenerFailed is reported when the Telemetry API listener cannot be started.
+	ListenerFailed ErrorType = "Extension.ListenerFailed"
+	// SubscribeFailed is reported when the extension cannot subscribe to the Telemetry API.
+	SubscribeFailed ErrorType = "Extension.SubscribeFailed"
+	// CollectorStartFailed is reported when the collector fails to start with a valid configuration.
+	CollectorStartFailed ErrorType = "Extension.CollectorStartFailed"
+	// CollectorCrashed is reported when the collector stops while the environment is running.
+	CollectorCrashed ErrorType = "Extension.CollectorCrashed"
+	// CollectorStopFailed is reported when the collector cannot be stopped on shutdown.
+	CollectorStopFailed ErrorType = "Extension.CollectorStopFailed"
+	// EventFailed is reported when the extension cannot get the next event.
+	EventFailed ErrorType = "Extension.EventFailed"
+	// Unknown is reported for errors that were not categorized.
+	Unknown ErrorType = "Extension.Unknown"
+)
+
+// maxStackDepth bounds the number of frames reported with an error.
+const maxStackDepth = 32
+
+// Error is an error categorized for the Extensions API.
+type Error struct {
+	Type  ErrorType
+	Err   error
+	stack []string
+}
+
+// NewError categorizes err and records the stack of the caller.
+func NewError(errorType ErrorType, err error) *Error {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var stack []string
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line))
+		if !more {
+			break
+		}
+	}
+	return &Error{Type: errorType, Err: err, stack: stack}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Type, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errorRequest is the body of the requests for /init/error and /exit/error
+type errorRequest struct {
+	ErrorMessage string   `json:"errorMessage"`
+	ErrorType    string   `json:"errorType"`
+	StackTrace   []string `json:"stackTrace,omitempty"`
+}
+
+// newErrorRequest returns the type and the body reported for err. Errors that were not categorized with NewError
+// are reported as Unknown.
+func newErrorRequest(err error) (ErrorType, errorRequest) {
+	var extErr *Error
+	if !errors.As(err, &extErr) {
+		extErr = &Error{Type: Unknown, Err: err}
+	}
+	return extErr.Type, errorRequest{
+		ErrorMessage: err.Error(),
+		ErrorType:    string(extErr.Type),
+		StackTrace:   extErr.stack,
+	}
+}
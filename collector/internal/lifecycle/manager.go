@@ -16,6 +16,7 @@ package lifecycle
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
 	"os"
@@ -23,12 +24,14 @@ import (
 	"path/filepath"
 	"sync"
 	"syscall"
+	"time"
 
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 
 	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/collector"
 	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/extensionapi"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/queuedrain"
 	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/telemetryapi"
 	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdacomponents"
 )
@@ -37,9 +40,26 @@ var (
 	extensionName = filepath.Base(os.Args[0]) // extension name has to match the filename
 )
 
+// shutdownDeadlineMargin is kept between stopping the collector and the shutdown deadline, so that the extension
+// can still report the end of the environment before it is killed.
+const shutdownDeadlineMargin = 50 * time.Millisecond
+
 type collectorWrapper interface {
 	Start(ctx context.Context) error
-	Stop() error
+	Stop(ctx context.Context) error
+	// Done returns a channel closed when the collector stops running and Err the error it stopped with.
+	Done() <-chan struct{}
+	Err() error
+}
+
+// configFallbackReporter is implemented by collectors that can fall back to a built-in configuration.
+type configFallbackReporter interface {
+	ConfigFallbackError() error
+}
+
+// componentStartupReporter is implemented by collectors that report how long each of their components took to start.
+type componentStartupReporter interface {
+	ComponentStartups() []lambdalifecycle.StartupPhase
 }
 
 type manager struct {
@@ -48,58 +68,144 @@ type manager struct {
 	extensionClient    *extensionapi.Client
 	listener           *telemetryapi.Listener
 	wg                 sync.WaitGroup
+	cancel             context.CancelCauseFunc
+	listenersMu        sync.Mutex
 	lifecycleListeners []lambdalifecycle.Listener
+	queueDrainer       *queuedrain.Drainer
+	startTime          time.Time
+	invocations        int
+	startup            lambdalifecycle.ExtensionStartup
+	accountID          string
+	supervisor         supervisor
+	reload             configReload
+	// invocationMu is held while the listeners are notified that an invocation starts or finishes, so that the
+	// listeners of a collector restarted meanwhile are notified of the invocation in flight, if any, in order.
+	invocationMu sync.Mutex
+	invoking     bool
 }
 
 func NewManager(ctx context.Context, logger *zap.Logger, version string) (context.Context, *manager) {
-	ctx, cancel := context.WithCancel(ctx)
+	ctx, cancel := context.WithCancelCause(ctx)
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
 	go func() {
 		s := <-sigs
-		cancel()
+		cancel(nil)
 		logger.Info("received signal", zap.String("signal", s.String()))
 	}()
 
+	// Size the runtime before anything is allocated for the collector.
+	collector.SetMemoryLimit(logger)
+
+	startup := lambdalifecycle.ExtensionStartup{Start: time.Now()}
+	phaseStart := startup.Start
+	endPhase := func(name string) {
+		now := time.Now()
+		startup.Phases = append(startup.Phases, lambdalifecycle.StartupPhase{Name: name, Start: phaseStart, End: now})
+		phaseStart = now
+	}
+
 	extensionClient := extensionapi.NewClient(logger, os.Getenv("AWS_LAMBDA_RUNTIME_API"))
 	res, err := extensionClient.Register(ctx, extensionName)
 	if err != nil {
 		logger.Fatal("Cannot register extension", zap.Error(err))
 	}
+	endPhase("extension.register")
 
-	listener := telemetryapi.NewListener(logger)
-	addr, err := listener.Start()
-	if err != nil {
-		logger.Fatal("Cannot start Telemetry API Listener", zap.Error(err))
-	}
-
-	telemetryClient := telemetryapi.NewClient(logger)
-	_, err = telemetryClient.Subscribe(ctx, []telemetryapi.EventType{telemetryapi.Platform}, res.ExtensionID, addr)
-	if err != nil {
-		logger.Fatal("Cannot register Telemetry API client", zap.Error(err))
+	// Without the Telemetry API, the extension still runs the collector but cannot tell when invocations complete.
+	listener, subscribeErr := subscribeTelemetryAPI(ctx, logger, res.ExtensionID, endPhase)
+	if subscribeErr != nil {
+		startup.Degraded = string(subscribeErr.Type)
+		logger.Warn("Telemetry API unavailable, running in degraded mode without platform.runtimeDone detection", zap.Error(subscribeErr))
 	}
 
 	lm := &manager{
 		logger:          logger.Named("lifecycle.manager"),
 		extensionClient: extensionClient,
 		listener:        listener,
+		startTime:       startup.Start,
+		cancel:          cancel,
+		accountID:       res.AccountID,
+		supervisor: supervisor{
+			policy:      collector.CollectorRestartPolicy(logger),
+			maxRestarts: collector.CollectorMaxRestarts(logger),
+		},
+		reload: configReload{
+			interval:    collector.ConfigReloadInterval(logger),
+			invocations: collector.ConfigReloadInvocations(logger),
+			last:        startup.Start,
+		},
 	}
 
 	factories, _ := lambdacomponents.Components(res.ExtensionID)
-	lm.collector = collector.NewCollector(logger, factories, version)
+	col := collector.NewCollector(logger, factories, version)
+	lm.queueDrainer = col.QueueDrainer()
+	col.ReportRestarts(lm.Restarts)
+	if subscribeErr != nil {
+		col.RunDegraded(subscribeErr.Type)
+	}
+	lm.collector = col
+	endPhase("collector.components")
+	lm.startup = startup
 
 	return ctx, lm
 }
 
+// subscribeTelemetryAPI starts the Telemetry API listener and subscribes it to platform events. The listener is only
+// returned if both succeed.
+func subscribeTelemetryAPI(ctx context.Context, logger *zap.Logger, extensionID string, endPhase func(name string)) (*telemetryapi.Listener, *extensionapi.Error) {
+	listener := telemetryapi.NewListener(logger)
+	addr, err := listener.Start()
+	if err != nil {
+		return nil, extensionapi.NewError(extensionapi.ListenerFailed, fmt.Errorf("cannot start Telemetry API listener: %w", err))
+	}
+	endPhase("telemetry_api.listener.start")
+
+	telemetryClient := telemetryapi.NewClient(logger)
+	_, err = telemetryClient.Subscribe(ctx, []telemetryapi.EventType{telemetryapi.Platform}, extensionID, addr)
+	if err != nil {
+		listener.Shutdown()
+		return nil, extensionapi.NewError(extensionapi.SubscribeFailed, fmt.Errorf("cannot subscribe to Telemetry API: %w", err))
+	}
+	endPhase("telemetry_api.subscribe")
+	return listener, nil
+}
+
 func (lm *manager) Run(ctx context.Context) error {
+	collectorStart := time.Now()
 	if err := lm.collector.Start(ctx); err != nil {
 		lm.logger.Warn("Failed to start the extension", zap.Error(err))
-		if _, initErr := lm.extensionClient.InitError(ctx, fmt.Sprintf("failed to start the collector: %v", err)); initErr != nil {
+		errorType := extensionapi.CollectorStartFailed
+		if errors.Is(err, collector.ErrInvalidConfig) {
+			errorType = extensionapi.ConfigInvalid
+		}
+		if _, initErr := lm.extensionClient.InitError(ctx, extensionapi.NewError(errorType, fmt.Errorf("failed to start the collector: %w", err))); initErr != nil {
 			return multierr.Combine(err, initErr)
 		}
 		return err
 	}
+	lm.startup.End = time.Now()
+	lm.startup.Phases = append(lm.startup.Phases, lambdalifecycle.StartupPhase{Name: "collector.start", Start: collectorStart, End: lm.startup.End})
+	if reporter, ok := lm.collector.(componentStartupReporter); ok {
+		for _, phase := range reporter.ComponentStartups() {
+			phase.Name = "collector.start." + phase.Name
+			lm.startup.Phases = append(lm.startup.Phases, phase)
+		}
+	}
+	if reporter, ok := lm.collector.(configFallbackReporter); ok {
+		if err := reporter.ConfigFallbackError(); err != nil {
+			lm.startup.ConfigFallback = err.Error()
+		}
+	}
+	lm.notifyExtensionStarted()
+
+	superviseCtx, stopSupervising := context.WithCancel(ctx)
+	supervised := make(chan struct{})
+	go func() {
+		defer close(supervised)
+		lm.superviseCollector(superviseCtx)
+	}()
 
 	lm.wg.Add(1)
 	go func() {
@@ -108,6 +214,8 @@ func (lm *manager) Run(ctx context.Context) error {
 		}
 	}()
 	lm.wg.Wait()
+	stopSupervising()
+	<-supervised
 	return nil
 }
 
@@ -117,13 +225,19 @@ func (lm *manager) processEvents(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
+			if crashErr := collectorCrash(ctx); crashErr != nil {
+				return lm.exitWithError(ctx, crashErr)
+			}
 			return nil
 		default:
 			lm.logger.Debug("Waiting for event...")
 			res, err := lm.extensionClient.NextEvent(ctx)
 			if err != nil {
+				if crashErr := collectorCrash(ctx); crashErr != nil {
+					return lm.exitWithError(ctx, crashErr)
+				}
 				lm.logger.Warn("error waiting for extension event", zap.Error(err))
-				if _, exitErr := lm.extensionClient.ExitError(ctx, fmt.Sprintf("error waiting for extension event: %v", err)); exitErr != nil {
+				if _, exitErr := lm.extensionClient.ExitError(ctx, extensionapi.NewError(extensionapi.EventFailed, fmt.Errorf("error waiting for extension event: %w", err))); exitErr != nil {
 					return multierr.Combine(err, exitErr)
 				}
 				return err
@@ -132,49 +246,232 @@ func (lm *manager) processEvents(ctx context.Context) error {
 			lm.logger.Debug("Received ", zap.Any("event :", res))
 			// Exit if we receive a SHUTDOWN event
 			if res.EventType == extensionapi.Shutdown {
-				lm.logger.Info("Received SHUTDOWN event")
-				lm.notifyEnvironmentShutdown()
-				lm.listener.Shutdown()
-				err = lm.collector.Stop()
+				info := lambdalifecycle.ShutdownInfo{Reason: string(res.ShutdownReason)}
+				if res.DeadlineMs > 0 {
+					info.Deadline = time.UnixMilli(res.DeadlineMs)
+				}
+				lm.logger.Info("Received SHUTDOWN event", zap.String("reason", info.Reason), zap.Time("deadline", info.Deadline))
+				shutdownCtx, cancelShutdown := shutdownContext(ctx, info)
+				defer cancelShutdown()
+				lm.notifyEnvironmentShutdown(shutdownCtx, info)
+				if lm.listener != nil {
+					lm.listener.Shutdown()
+				}
+				// The queues are sampled before the collector is stopped, as its exporters no longer report them once
+				// shut down.
+				pending := lm.pendingItems()
+				err = lm.stopSupervisedCollector(shutdownCtx)
+				lm.reportEndOfLife(info, pending, err)
 				if err != nil {
-					if _, exitErr := lm.extensionClient.ExitError(ctx, fmt.Sprintf("error stopping collector: %v", err)); exitErr != nil {
+					if _, exitErr := lm.extensionClient.ExitError(ctx, extensionapi.NewError(extensionapi.CollectorStopFailed, fmt.Errorf("error stopping collector: %w", err))); exitErr != nil {
 						return multierr.Combine(err, exitErr)
 					}
 				}
 				return err
 			}
 
+			lm.invocations++
 			lm.notifyFunctionInvoked()
 
-			err = lm.listener.Wait(ctx, res.RequestID)
-			if err != nil {
-				lm.logger.Error("problem waiting for platform.runtimeDone event", zap.Error(err), zap.String("requestID", res.RequestID))
-			}
+			lm.waitRuntimeDone(ctx, res.RequestID)
+			runtimeDone := time.Now()
 
 			// Check other components are ready before allowing the freezing of the environment.
-			lm.notifyFunctionFinished()
+			timings := lm.notifyFunctionFinished(ctx)
+			if lm.reload.due(time.Now()) {
+				start := time.Now()
+				lm.reloadConfig(ctx)
+				timings = append(timings, lambdalifecycle.ListenerTiming{Name: "config reload", Duration: time.Since(start)})
+			}
+
+			lm.notifyInvocationOverhead(lambdalifecycle.InvocationOverhead{
+				RequestID:   res.RequestID,
+				RuntimeDone: runtimeDone,
+				End:         time.Now(),
+				Listeners:   timings,
+			})
+		}
+	}
+}
+
+// waitRuntimeDone waits for the runtime to complete the invocation. In degraded mode, the completion is unknown and
+// the listeners are notified right away, so data produced during the invocation may only be exported later.
+func (lm *manager) waitRuntimeDone(ctx context.Context, requestID string) {
+	if lm.listener == nil {
+		return
+	}
+	done, err := lm.listener.Wait(ctx, requestID)
+	if err != nil {
+		lm.logger.Error("problem waiting for platform.runtimeDone event", zap.Error(err), zap.String("requestID", requestID))
+		return
+	}
+	lm.logger.Debug("Invocation completed", zap.String("requestID", requestID), zap.String("status", done.Status), zap.String("errorType", done.ErrorType))
+}
+
+// notifyExtensionStarted reports the extension startup. Components such as receivers register as listeners while the
+// collector starts, so this can only happen once it has started.
+func (lm *manager) notifyExtensionStarted() {
+	lm.logger.Debug("Extension started", zap.Duration("startup", lm.startup.End.Sub(lm.startup.Start)))
+	for _, listener := range lm.listeners() {
+		if l, ok := listener.(lambdalifecycle.StartupListener); ok {
+			l.ExtensionStarted(lm.startup)
 		}
 	}
 }
 
 func (lm *manager) notifyFunctionInvoked() {
-	for _, listener := range lm.lifecycleListeners {
+	lm.invocationMu.Lock()
+	defer lm.invocationMu.Unlock()
+	lm.invoking = true
+	for _, listener := range lm.listeners() {
+		listener.FunctionInvoked()
+	}
+}
+
+// replayInvocation notifies the listeners of a collector restarted during an invocation that the invocation is in
+// flight, as they registered after it started. Otherwise, components such as the decouple processor would hold its
+// data until the next invocation.
+func (lm *manager) replayInvocation() {
+	lm.invocationMu.Lock()
+	defer lm.invocationMu.Unlock()
+	if !lm.invoking {
+		return
+	}
+	for _, listener := range lm.listeners() {
 		listener.FunctionInvoked()
 	}
 }
 
-func (lm *manager) notifyFunctionFinished() {
-	for _, listener := range lm.lifecycleListeners {
+// notifyFunctionFinished notifies the listeners and returns the time spent by each of them. Listeners of the same type
+// are reported together.
+func (lm *manager) notifyFunctionFinished(ctx context.Context) []lambdalifecycle.ListenerTiming {
+	var timings []lambdalifecycle.ListenerTiming
+	record := func(name string, start time.Time) {
+		for i := range timings {
+			if timings[i].Name == name {
+				timings[i].Duration += time.Since(start)
+				return
+			}
+		}
+		timings = append(timings, lambdalifecycle.ListenerTiming{Name: name, Duration: time.Since(start)})
+	}
+
+	lm.invocationMu.Lock()
+	lm.invoking = false
+	for _, listener := range lm.listeners() {
+		start := time.Now()
 		listener.FunctionFinished()
+		record(fmt.Sprintf("%T", listener), start)
+	}
+	lm.invocationMu.Unlock()
+	// Listeners such as the decouple processor may still hand data to the exporters, so the queues are only drained
+	// once all of them have returned.
+	if lm.queueDrainer != nil {
+		start := time.Now()
+		lm.drainExporterQueues(ctx)
+		record("exporter queue drain", start)
+	}
+	return timings
+}
+
+func (lm *manager) notifyInvocationOverhead(overhead lambdalifecycle.InvocationOverhead) {
+	lm.logger.Debug("Invocation overhead",
+		zap.String("requestID", overhead.RequestID),
+		zap.Duration("post_invoke", overhead.End.Sub(overhead.RuntimeDone)),
+	)
+	for _, listener := range lm.listeners() {
+		if l, ok := listener.(lambdalifecycle.InvocationOverheadListener); ok {
+			l.InvocationOverhead(overhead)
+		}
+	}
+}
+
+// shutdownContext returns the context bounding the shutdown of the environment, which is done shortly before the
+// shutdown deadline, if Lambda reported it.
+func shutdownContext(ctx context.Context, info lambdalifecycle.ShutdownInfo) (context.Context, context.CancelFunc) {
+	if info.Deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, info.Deadline.Add(-shutdownDeadlineMargin))
+}
+
+// notifyEnvironmentShutdown notifies the listeners, then drains the exporter queues, giving up once ctx is done.
+// Listeners still running by then are left behind, so that the collector can be stopped before the deadline.
+func (lm *manager) notifyEnvironmentShutdown(ctx context.Context, info lambdalifecycle.ShutdownInfo) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, listener := range lm.listeners() {
+			if l, ok := listener.(lambdalifecycle.ShutdownInfoListener); ok {
+				l.EnvironmentShutdownWithInfo(info)
+			} else {
+				listener.EnvironmentShutdown()
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		lm.logger.Warn("Listeners did not handle the environment shutdown before the deadline", zap.Error(ctx.Err()))
+		return
+	}
+	lm.drainExporterQueues(ctx)
+}
+
+// pendingItems returns the number of items that have not been exported yet, held by the listeners, such as the
+// decouple processor and its retry buffer, or by the sending queues of the exporters.
+func (lm *manager) pendingItems() int {
+	pending := 0
+	for _, listener := range lm.listeners() {
+		if r, ok := listener.(lambdalifecycle.PendingReporter); ok {
+			pending += r.Pending()
+		}
+	}
+	if lm.queueDrainer != nil {
+		pending += int(lm.queueDrainer.QueueSize(context.Background()))
 	}
+	return pending
 }
 
-func (lm *manager) notifyEnvironmentShutdown() {
-	for _, listener := range lm.lifecycleListeners {
-		listener.EnvironmentShutdown()
+// reportEndOfLife logs a summary of the lifetime of the environment, including the pending items that could not be
+// exported before the collector was stopped.
+func (lm *manager) reportEndOfLife(info lambdalifecycle.ShutdownInfo, pending int, stopErr error) {
+	fields := []zap.Field{
+		zap.String("shutdown_reason", info.Reason),
+		zap.Int("invocations", lm.invocations),
+		zap.Duration("environment_age", time.Since(lm.startTime)),
+		zap.Int("unsent_items", pending),
+		zap.Int("collector_restarts", lm.Restarts()),
+		zap.Error(stopErr),
 	}
+	if pending > 0 || stopErr != nil {
+		lm.logger.Warn("Environment shutdown report", fields...)
+		return
+	}
+	lm.logger.Info("Environment shutdown report", fields...)
+}
+
+func (lm *manager) drainExporterQueues(ctx context.Context) {
+	if lm.queueDrainer != nil {
+		lm.queueDrainer.Drain(ctx)
+	}
+}
+
+// AccountID returns the AWS account ID of the function returned on registration, if any.
+func (lm *manager) AccountID() string {
+	return lm.accountID
 }
 
 func (lm *manager) AddListener(listener lambdalifecycle.Listener) {
+	lm.listenersMu.Lock()
+	defer lm.listenersMu.Unlock()
 	lm.lifecycleListeners = append(lm.lifecycleListeners, listener)
 }
+
+// listeners returns the current listeners. Components register while the collector starts, which can happen again
+// while events are processed if the collector is restarted.
+func (lm *manager) listeners() []lambdalifecycle.Listener {
+	lm.listenersMu.Lock()
+	defer lm.listenersMu.Unlock()
+	return lm.lifecycleListeners
+}
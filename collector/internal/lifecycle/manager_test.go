@@ -22,24 +22,88 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/collector"
 	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/extensionapi"
 	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/telemetryapi"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
 )
 
 type MockCollector struct {
-	err error
+	err         error
+	done        chan struct{}
+	components  []lambdalifecycle.StartupPhase
+	fallbackErr error
+	// stopped is called when the collector is stopped, if set.
+	stopped func()
 }
 
 func (c *MockCollector) Start(ctx context.Context) error {
 	return c.err
 }
-func (c *MockCollector) Stop() error {
+func (c *MockCollector) Stop(ctx context.Context) error {
+	if c.stopped != nil {
+		c.stopped()
+	}
+	return c.err
+}
+func (c *MockCollector) Done() <-chan struct{} {
+	return c.done
+}
+func (c *MockCollector) Err() error {
 	return c.err
 }
+func (c *MockCollector) ComponentStartups() []lambdalifecycle.StartupPhase {
+	return c.components
+}
+func (c *MockCollector) ConfigFallbackError() error {
+	return c.fallbackErr
+}
+
+type MockOverheadListener struct {
+	overheads []lambdalifecycle.InvocationOverhead
+}
+
+func (l *MockOverheadListener) FunctionInvoked() {}
+func (l *MockOverheadListener) FunctionFinished() {
+	time.Sleep(10 * time.Millisecond)
+}
+func (l *MockOverheadListener) EnvironmentShutdown() {}
+func (l *MockOverheadListener) InvocationOverhead(overhead lambdalifecycle.InvocationOverhead) {
+	l.overheads = append(l.overheads, overhead)
+}
+
+type MockStartupListener struct {
+	startups []lambdalifecycle.ExtensionStartup
+}
+
+func (l *MockStartupListener) FunctionInvoked()     {}
+func (l *MockStartupListener) FunctionFinished()    {}
+func (l *MockStartupListener) EnvironmentShutdown() {}
+func (l *MockStartupListener) ExtensionStarted(startup lambdalifecycle.ExtensionStartup) {
+	l.startups = append(l.startups, startup)
+}
+
+type MockShutdownListener struct {
+	info    lambdalifecycle.ShutdownInfo
+	pending int
+}
+
+func (l *MockShutdownListener) FunctionInvoked()     {}
+func (l *MockShutdownListener) FunctionFinished()    {}
+func (l *MockShutdownListener) EnvironmentShutdown() {}
+func (l *MockShutdownListener) EnvironmentShutdownWithInfo(info lambdalifecycle.ShutdownInfo) {
+	l.info = info
+}
+func (l *MockShutdownListener) Pending() int {
+	return l.pending
+}
 
 func TestRun(t *testing.T) {
 	logger := zaptest.NewLogger(t)
@@ -157,3 +221,180 @@ func TestProcessEvents(t *testing.T) {
 	}
 
 }
+
+func TestShutdownInfo(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	deadline := time.Now().Add(time.Minute).UnixMilli()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		_, err := fmt.Fprintf(w, `{"eventType":"SHUTDOWN", "shutdownReason":"timeout", "deadlineMs":%d}`, deadline)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	listener := &MockShutdownListener{pending: 3}
+	lm := manager{
+		// The pending items are flushed or dropped when the collector is stopped, so they are counted before.
+		collector:       &MockCollector{stopped: func() { listener.pending = 0 }},
+		logger:          logger,
+		listener:        telemetryapi.NewListener(logger),
+		extensionClient: extensionapi.NewClient(logger, u.Host),
+		invocations:     2,
+	}
+	lm.AddListener(listener)
+	lm.wg.Add(1)
+	require.NoError(t, lm.processEvents(context.Background()))
+
+	require.Equal(t, "timeout", listener.info.Reason)
+	require.Equal(t, deadline, listener.info.Deadline.UnixMilli())
+
+	reports := logs.FilterMessage("Environment shutdown report").All()
+	require.Len(t, reports, 1)
+	fields := reports[0].ContextMap()
+	require.Equal(t, "timeout", fields["shutdown_reason"])
+	require.Equal(t, int64(2), fields["invocations"])
+	require.Equal(t, int64(3), fields["unsent_items"])
+}
+
+// BlockingShutdownListener does not return from EnvironmentShutdown until release is closed.
+type BlockingShutdownListener struct {
+	release chan struct{}
+}
+
+func (l *BlockingShutdownListener) FunctionInvoked()  {}
+func (l *BlockingShutdownListener) FunctionFinished() {}
+func (l *BlockingShutdownListener) EnvironmentShutdown() {
+	<-l.release
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	deadline := time.Now().Add(shutdownDeadlineMargin + 200*time.Millisecond)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		_, err := fmt.Fprintf(w, `{"eventType":"SHUTDOWN", "shutdownReason":"spindown", "deadlineMs":%d}`, deadline.UnixMilli())
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	listener := &BlockingShutdownListener{release: make(chan struct{})}
+	defer close(listener.release)
+	lm := manager{
+		collector:       &MockCollector{},
+		logger:          logger,
+		listener:        telemetryapi.NewListener(logger),
+		extensionClient: extensionapi.NewClient(logger, u.Host),
+	}
+	lm.AddListener(listener)
+	lm.wg.Add(1)
+
+	// The collector is stopped before the deadline even though a listener is still handling the shutdown.
+	require.NoError(t, lm.processEvents(context.Background()))
+	require.True(t, time.Now().Before(deadline))
+}
+
+func TestInvocationOverhead(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	listener := &MockOverheadListener{}
+	lm := manager{logger: logger}
+	lm.AddListener(listener)
+	lm.AddListener(&MockOverheadListener{})
+
+	timings := lm.notifyFunctionFinished(context.Background())
+	require.Len(t, timings, 1, "listeners of the same type are reported together")
+	require.Equal(t, "*lifecycle.MockOverheadListener", timings[0].Name)
+	require.GreaterOrEqual(t, timings[0].Duration, 20*time.Millisecond)
+
+	overhead := lambdalifecycle.InvocationOverhead{RequestID: "request-1", Listeners: timings}
+	lm.notifyInvocationOverhead(overhead)
+	require.Equal(t, []lambdalifecycle.InvocationOverhead{overhead}, listener.overheads)
+}
+
+func TestExtensionStarted(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	lm := manager{
+		collector: &MockCollector{
+			components:  []lambdalifecycle.StartupPhase{{Name: "receiver/otlp", Start: start, End: start}},
+			fallbackErr: collector.ErrInvalidConfig,
+		},
+		logger: logger,
+		startup: lambdalifecycle.ExtensionStartup{
+			Start:  start,
+			Phases: []lambdalifecycle.StartupPhase{{Name: "extension.register", Start: start, End: start}},
+		},
+	}
+	listener := &MockStartupListener{}
+	lm.AddListener(listener)
+	require.NoError(t, lm.Run(ctx))
+
+	require.Len(t, listener.startups, 1)
+	startup := listener.startups[0]
+	require.Len(t, startup.Phases, 3)
+	require.Equal(t, "collector.start", startup.Phases[1].Name)
+	require.Equal(t, startup.End, startup.Phases[1].End)
+	require.Equal(t, "collector.start.receiver/otlp", startup.Phases[2].Name)
+	require.Equal(t, collector.ErrInvalidConfig.Error(), startup.ConfigFallback)
+	require.False(t, startup.End.Before(start))
+}
+
+func TestRunReportsErrorType(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	var errorType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errorType = r.Header.Get("Lambda-Extension-Function-Error-Type")
+		_, _ = w.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	lm := manager{
+		collector:       &MockCollector{err: fmt.Errorf("%w: failed to get config", collector.ErrInvalidConfig)},
+		logger:          logger,
+		extensionClient: extensionapi.NewClient(logger, u.Host),
+	}
+	require.Error(t, lm.Run(context.Background()))
+	require.Equal(t, string(extensionapi.ConfigInvalid), errorType)
+
+	lm.collector = &MockCollector{err: fmt.Errorf("port in use")}
+	require.Error(t, lm.Run(context.Background()))
+	require.Equal(t, string(extensionapi.CollectorStartFailed), errorType)
+}
+
+func TestProcessEventsDegraded(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	events := []string{
+		`{"eventType":"INVOKE", "requestId":"request-1"}`,
+		`{"eventType":"SHUTDOWN", "shutdownReason":"spindown"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(events[0]))
+		require.NoError(t, err)
+		events = events[1:]
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	// Without a Telemetry API listener, listeners are notified as soon as the invocation is received.
+	lm := manager{
+		collector:       &MockCollector{},
+		logger:          logger,
+		extensionClient: extensionapi.NewClient(logger, u.Host),
+	}
+	listener := &MockOverheadListener{}
+	lm.AddListener(listener)
+	lm.wg.Add(1)
+	require.NoError(t, lm.processEvents(context.Background()))
+	require.Len(t, listener.overheads, 1)
+	require.Equal(t, "request-1", listener.overheads[0].RequestID)
+}
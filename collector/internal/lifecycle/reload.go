@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// configReloader is implemented by collectors that can restart with a configuration that changed since they started.
+type configReloader interface {
+	Reload(ctx context.Context) (bool, error)
+}
+
+// configReload tells when the configuration is checked for changes, every interval or every number of invocations.
+type configReload struct {
+	interval    time.Duration
+	invocations int
+	last        time.Time
+	sinceLast   int
+}
+
+// due is called once per invocation and returns whether the configuration must be checked.
+func (r *configReload) due(now time.Time) bool {
+	r.sinceLast++
+	if (r.interval > 0 && now.Sub(r.last) >= r.interval) || (r.invocations > 0 && r.sinceLast >= r.invocations) {
+		r.last = now
+		r.sinceLast = 0
+		return true
+	}
+	return false
+}
+
+// reloadConfig restarts the collector if its configuration changed. It is called between invocations, after the
+// listeners are notified that the function finished, so that no invocation is served by two collectors.
+func (lm *manager) reloadConfig(ctx context.Context) {
+	reloader, ok := lm.collector.(configReloader)
+	if !ok {
+		return
+	}
+	lm.supervisor.mu.Lock()
+	defer lm.supervisor.mu.Unlock()
+
+	// Components of the new collector register while it starts. The listeners are kept if it is not restarted.
+	lm.listenersMu.Lock()
+	previous := lm.lifecycleListeners
+	lm.lifecycleListeners = nil
+	lm.listenersMu.Unlock()
+
+	restarted, err := reloader.Reload(ctx)
+	if !restarted {
+		lm.listenersMu.Lock()
+		lm.lifecycleListeners = previous
+		lm.listenersMu.Unlock()
+	}
+	switch {
+	case err != nil && restarted:
+		lm.logger.Error("Failed to apply the new configuration, running the previous one", zap.Error(err))
+	case err != nil:
+		lm.logger.Warn("Failed to reload the configuration, running the previous one", zap.Error(err))
+	case restarted:
+		lm.logger.Info("Configuration changed, collector restarted")
+	default:
+		lm.logger.Debug("Configuration unchanged")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/extensionapi"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+// ReloadingCollector returns restarted and reloadErr on every reload. A restarted collector registers a new listener.
+type ReloadingCollector struct {
+	MockCollector
+	manager   *manager
+	reloads   int
+	restarted bool
+	reloadErr error
+	listener  *MockOverheadListener
+}
+
+func (c *ReloadingCollector) Reload(ctx context.Context) (bool, error) {
+	c.reloads++
+	if c.restarted {
+		c.listener = &MockOverheadListener{}
+		c.manager.AddListener(c.listener)
+	}
+	return c.restarted, c.reloadErr
+}
+
+func TestConfigReloadDue(t *testing.T) {
+	start := time.Now()
+	reload := configReload{invocations: 2, last: start}
+	require.False(t, reload.due(start))
+	require.True(t, reload.due(start))
+	require.False(t, reload.due(start))
+
+	reload = configReload{interval: time.Minute, last: start}
+	require.False(t, reload.due(start.Add(time.Second)))
+	require.True(t, reload.due(start.Add(time.Minute)))
+	require.False(t, reload.due(start.Add(time.Minute+time.Second)))
+
+	reload = configReload{last: start}
+	require.False(t, reload.due(start.Add(time.Hour)))
+}
+
+func TestProcessEventsReloadsConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		restarted bool
+		reloadErr error
+	}{
+		{name: "unchanged"},
+		{name: "invalid", reloadErr: errors.New("invalid configuration")},
+		{name: "restarted", restarted: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := zaptest.NewLogger(t)
+			events := []string{
+				`{"eventType":"INVOKE", "requestId":"request-1"}`,
+				`{"eventType":"INVOKE", "requestId":"request-2"}`,
+				`{"eventType":"SHUTDOWN", "shutdownReason":"spindown"}`,
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := w.Write([]byte(events[0]))
+				require.NoError(t, err)
+				events = events[1:]
+			}))
+			defer server.Close()
+			u, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			lm := &manager{
+				logger:          logger,
+				extensionClient: extensionapi.NewClient(logger, u.Host),
+				reload:          configReload{invocations: 2},
+			}
+			col := &ReloadingCollector{manager: lm, restarted: tc.restarted, reloadErr: tc.reloadErr}
+			lm.collector = col
+			listener := &MockOverheadListener{}
+			lm.AddListener(listener)
+			lm.wg.Add(1)
+			require.NoError(t, lm.processEvents(context.Background()))
+
+			// The configuration is checked after the second invocation, and reported in its overhead.
+			require.Equal(t, 1, col.reloads)
+			if !tc.restarted {
+				require.Len(t, listener.overheads, 2)
+				require.Equal(t, []lambdalifecycle.Listener{listener}, lm.listeners())
+				return
+			}
+			// Listeners of the previous collector are dropped when it is restarted.
+			require.Len(t, listener.overheads, 1)
+			require.Len(t, col.listener.overheads, 1)
+			overhead := col.listener.overheads[0]
+			require.Equal(t, "request-2", overhead.RequestID)
+			require.Equal(t, "config reload", overhead.Listeners[len(overhead.Listeners)-1].Name)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/extensionapi"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle/runtimeapitest"
+)
+
+// otlpSink counts the OTLP/HTTP requests received by path.
+type otlpSink struct {
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func (s *otlpSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *otlpSink) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// runManager runs the extension against server with config, as main does, and returns the error Run returned.
+func runManager(t *testing.T, server *runtimeapitest.Server, config string) error {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+	t.Setenv("OPENTELEMETRY_COLLECTOR_CONFIG_URI", path)
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", server.Addr)
+	t.Setenv("AWS_SAM_LOCAL", "true")
+
+	ctx, lm := NewManager(context.Background(), zaptest.NewLogger(t), "test")
+	lambdalifecycle.SetNotifier(lm)
+	done := make(chan error, 1)
+	go func() {
+		done <- lm.Run(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(30 * time.Second):
+		t.Fatal("the extension did not exit")
+		return nil
+	}
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// TestRuntimeAPI runs the extension through the initialization, two invocations and the shutdown of an environment.
+func TestRuntimeAPI(t *testing.T) {
+	sink := &otlpSink{requests: make(map[string]int)}
+	backend := httptest.NewServer(sink)
+	defer backend.Close()
+	server := runtimeapitest.NewServer(runtimeapitest.Script{
+		Init: runtimeapitest.InitTelemetry(),
+		Events: []runtimeapitest.Event{
+			runtimeapitest.Invoke("request-1"),
+			runtimeapitest.Invoke("request-2"),
+			runtimeapitest.Shutdown("spindown"),
+		},
+	})
+	defer server.Close()
+
+	require.NoError(t, runManager(t, server, fmt.Sprintf(`
+receivers:
+  telemetryapireceiver:
+    port: %d
+exporters:
+  otlphttp:
+    endpoint: %s
+service:
+  pipelines:
+    traces:
+      receivers: [telemetryapireceiver]
+      exporters: [otlphttp]
+    metrics:
+      receivers: [telemetryapireceiver]
+      exporters: [otlphttp]
+    logs:
+      receivers: [telemetryapireceiver]
+      exporters: [otlphttp]
+  telemetry:
+    metrics:
+      level: none
+`, freePort(t), backend.URL)))
+
+	require.Empty(t, server.InitErrors())
+	require.Empty(t, server.ExitErrors())
+	require.Empty(t, server.DeliveryErrors())
+	require.Equal(t, "123456789012", lambdalifecycle.AccountID(), "the account ID returned on registration is exposed to components")
+
+	// The extension subscribes to platform events to detect the end of invocations, the receiver to all events.
+	subscriptions := server.Subscriptions()
+	require.Len(t, subscriptions, 2)
+	require.Equal(t, []string{"platform"}, subscriptions[0].Types)
+	require.Equal(t, subscriptions[0].ExtensionID, subscriptions[1].ExtensionID)
+
+	// The telemetry of each invocation is exported before the environment can be frozen.
+	for _, path := range []string{"/v1/traces", "/v1/metrics", "/v1/logs"} {
+		require.Positive(t, sink.count(path), path)
+	}
+}
+
+// TestRuntimeAPIInitError reports an invalid configuration to /init/error.
+func TestRuntimeAPIInitError(t *testing.T) {
+	server := runtimeapitest.NewServer(runtimeapitest.Script{})
+	defer server.Close()
+
+	require.Error(t, runManager(t, server, `
+receivers:
+  telemetryapireceiver:
+service:
+  pipelines:
+    traces:
+      receivers: [telemetryapireceiver]
+      exporters: [unknown]
+`))
+
+	initErrors := server.InitErrors()
+	require.Len(t, initErrors, 1)
+	require.Equal(t, string(extensionapi.ConfigInvalid), initErrors[0].Type)
+	require.Contains(t, initErrors[0].Message, "failed to start the collector")
+}
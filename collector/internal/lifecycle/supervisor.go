@@ -0,0 +1,132 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/collector"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/extensionapi"
+)
+
+// supervisor holds the state of the collector supervision.
+type supervisor struct {
+	// mu is held while the collector is restarted, by the supervisor or by a configuration reload.
+	mu          sync.Mutex
+	policy      collector.RestartPolicy
+	maxRestarts int
+	// restarts is the number of times the collector was restarted after stopping unexpectedly.
+	restarts atomic.Int32
+	// stopping is set once the manager stops the collector, so that it is not mistaken for a crash.
+	stopping atomic.Bool
+}
+
+// Restarts returns the number of times the collector was restarted after stopping unexpectedly.
+func (lm *manager) Restarts() int {
+	return int(lm.supervisor.restarts.Load())
+}
+
+// superviseCollector watches the collector until ctx is done or the manager stops it. If the collector stops on its
+// own, for example because a component panicked, it is restarted with the same configuration according to the restart
+// policy. Otherwise, the event loop is cancelled with an Extension.CollectorCrashed error, which is reported to Lambda.
+func (lm *manager) superviseCollector(ctx context.Context) {
+	for {
+		lm.supervisor.mu.Lock()
+		done := lm.collector.Done()
+		lm.supervisor.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+		}
+		if !lm.handleCollectorStop(ctx, done) {
+			return
+		}
+	}
+}
+
+// handleCollectorStop restarts the collector that stopped running, as signaled by done, and returns whether the
+// collector is still supervised.
+func (lm *manager) handleCollectorStop(ctx context.Context, done <-chan struct{}) bool {
+	lm.supervisor.mu.Lock()
+	defer lm.supervisor.mu.Unlock()
+	if lm.supervisor.stopping.Load() || ctx.Err() != nil {
+		return false
+	}
+	// The collector was restarted by a configuration reload.
+	if lm.collector.Done() != done {
+		return true
+	}
+
+	crashErr := lm.collector.Err()
+	if crashErr == nil {
+		crashErr = errors.New("collector stopped unexpectedly")
+	}
+	restarts := lm.Restarts()
+	lm.logger.Error("Collector stopped unexpectedly", zap.Error(crashErr), zap.Int("restarts", restarts))
+	if lm.supervisor.policy != collector.RestartPolicyRestart || restarts >= lm.supervisor.maxRestarts {
+		lm.cancel(extensionapi.NewError(extensionapi.CollectorCrashed, fmt.Errorf("collector stopped after %d restarts: %w", restarts, crashErr)))
+		return false
+	}
+
+	// Components of the crashed collector are gone, the new ones register again while the collector starts.
+	lm.listenersMu.Lock()
+	lm.lifecycleListeners = nil
+	lm.listenersMu.Unlock()
+
+	lm.supervisor.restarts.Add(1)
+	if err := lm.collector.Start(ctx); err != nil {
+		lm.cancel(extensionapi.NewError(extensionapi.CollectorCrashed, fmt.Errorf("failed to restart the collector: %w", err)))
+		return false
+	}
+	lm.logger.Info("Collector restarted", zap.Int("restarts", lm.Restarts()))
+	lm.replayInvocation()
+	return true
+}
+
+// stopSupervisedCollector stops the collector for good, giving up once ctx is done. The supervisor lock is held while
+// it stops, so that the collector is neither restarted after a crash nor by a configuration reload in the meantime,
+// which would leave the new collector running.
+func (lm *manager) stopSupervisedCollector(ctx context.Context) error {
+	lm.supervisor.mu.Lock()
+	defer lm.supervisor.mu.Unlock()
+	lm.supervisor.stopping.Store(true)
+	return lm.collector.Stop(ctx)
+}
+
+// collectorCrash returns the error the supervisor cancelled the event loop with, if it did.
+func collectorCrash(ctx context.Context) *extensionapi.Error {
+	var extErr *extensionapi.Error
+	if errors.As(context.Cause(ctx), &extErr) {
+		return extErr
+	}
+	return nil
+}
+
+// exitWithError reports err to Lambda before the extension exits. It is reported even though ctx is done.
+func (lm *manager) exitWithError(ctx context.Context, err error) error {
+	if _, exitErr := lm.extensionClient.ExitError(context.WithoutCancel(ctx), err); exitErr != nil {
+		return multierr.Combine(err, exitErr)
+	}
+	return err
+}
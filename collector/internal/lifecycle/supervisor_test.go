@@ -0,0 +1,284 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/collector"
+	"github.com/open-telemetry/opentelemetry-lambda/collector/internal/extensionapi"
+)
+
+// CrashingCollector stops unexpectedly every time it is started, for the first crash starts.
+type CrashingCollector struct {
+	mu     sync.Mutex
+	starts int
+	crash  int
+	done   chan struct{}
+}
+
+func (c *CrashingCollector) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.starts++
+	c.done = make(chan struct{})
+	if c.starts <= c.crash {
+		close(c.done)
+	}
+	return nil
+}
+func (c *CrashingCollector) Stop(ctx context.Context) error { return nil }
+func (c *CrashingCollector) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done
+}
+func (c *CrashingCollector) Err() error { return errors.New("component panicked") }
+
+func TestSuperviseCollectorRestarts(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	col := &CrashingCollector{crash: 2}
+	require.NoError(t, col.Start(ctx))
+	lm := manager{
+		collector:  col,
+		logger:     logger,
+		cancel:     cancel,
+		supervisor: supervisor{policy: collector.RestartPolicyRestart, maxRestarts: 3},
+	}
+	lm.AddListener(&MockOverheadListener{})
+
+	go lm.superviseCollector(ctx)
+	require.Eventually(t, func() bool { return lm.Restarts() == 2 }, time.Second, time.Millisecond)
+	require.Empty(t, lm.listeners(), "listeners of the crashed collector are removed")
+	require.NoError(t, context.Cause(ctx))
+}
+
+func TestSuperviseCollectorExits(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		policy           collector.RestartPolicy
+		expectedRestarts int
+	}{
+		{desc: "exit policy", policy: collector.RestartPolicyExit, expectedRestarts: 0},
+		{desc: "too many restarts", policy: collector.RestartPolicyRestart, expectedRestarts: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			logger := zaptest.NewLogger(t)
+			var errorType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				errorType = r.Header.Get("Lambda-Extension-Function-Error-Type")
+				_, _ = w.Write([]byte(`{"status":"OK"}`))
+			}))
+			defer server.Close()
+			u, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancelCause(context.Background())
+			col := &CrashingCollector{crash: 5}
+			require.NoError(t, col.Start(ctx))
+			lm := manager{
+				collector:       col,
+				logger:          logger,
+				cancel:          cancel,
+				extensionClient: extensionapi.NewClient(logger, u.Host),
+				supervisor:      supervisor{policy: tc.policy, maxRestarts: 1},
+			}
+
+			lm.superviseCollector(ctx)
+			require.Equal(t, tc.expectedRestarts, lm.Restarts())
+			require.NotNil(t, collectorCrash(ctx))
+
+			// The event loop reports the crash and exits.
+			lm.wg.Add(1)
+			err = lm.processEvents(ctx)
+			require.ErrorContains(t, err, "component panicked")
+			require.Equal(t, string(extensionapi.CollectorCrashed), errorType)
+		})
+	}
+}
+
+func TestSuperviseCollectorStopped(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	col := &CrashingCollector{crash: 1}
+	require.NoError(t, col.Start(ctx))
+	lm := manager{collector: col, logger: zaptest.NewLogger(t), cancel: cancel}
+	lm.supervisor.stopping.Store(true)
+
+	lm.superviseCollector(ctx)
+	require.Equal(t, 0, lm.Restarts())
+	require.NoError(t, context.Cause(ctx))
+}
+
+// RunningCollector counts the collectors it started that were not stopped yet, a crash stops the running one. Starts
+// are signaled on starting, if set, and take a while.
+type RunningCollector struct {
+	mu       sync.Mutex
+	running  int
+	done     chan struct{}
+	starting chan struct{}
+}
+
+func (c *RunningCollector) Start(ctx context.Context) error {
+	if c.starting != nil {
+		c.starting <- struct{}{}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running++
+	c.done = make(chan struct{})
+	return nil
+}
+func (c *RunningCollector) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopLocked()
+	return nil
+}
+func (c *RunningCollector) crash() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopLocked()
+}
+func (c *RunningCollector) stopLocked() {
+	select {
+	case <-c.done:
+	default:
+		c.running--
+		close(c.done)
+	}
+}
+func (c *RunningCollector) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done
+}
+func (c *RunningCollector) Err() error { return errors.New("component panicked") }
+func (c *RunningCollector) Running() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running
+}
+
+// TestSuperviseCollectorCrashDuringShutdown stops the collector for the shutdown while the supervisor restarts it after
+// a crash, the restarted collector must be stopped as well.
+func TestSuperviseCollectorCrashDuringShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	col := &RunningCollector{}
+	require.NoError(t, col.Start(ctx))
+	col.starting = make(chan struct{}, 1)
+	lm := manager{
+		collector:  col,
+		logger:     zaptest.NewLogger(t),
+		cancel:     cancel,
+		supervisor: supervisor{policy: collector.RestartPolicyRestart, maxRestarts: 3},
+	}
+
+	supervised := make(chan struct{})
+	go func() {
+		defer close(supervised)
+		lm.superviseCollector(ctx)
+	}()
+	col.crash()
+	<-col.starting
+	require.NoError(t, lm.stopSupervisedCollector(ctx))
+	select {
+	case <-supervised:
+	case <-time.After(time.Second):
+		t.Fatal("the restarted collector is still supervised")
+	}
+
+	require.Equal(t, 1, lm.Restarts())
+	require.Zero(t, col.Running(), "no collector is left running")
+	require.NoError(t, context.Cause(ctx))
+}
+
+// InvocationListener counts the lifecycle events it is notified of.
+type InvocationListener struct {
+	invoked  atomic.Int32
+	finished atomic.Int32
+}
+
+func (l *InvocationListener) FunctionInvoked()     { l.invoked.Add(1) }
+func (l *InvocationListener) FunctionFinished()    { l.finished.Add(1) }
+func (l *InvocationListener) EnvironmentShutdown() {}
+
+// RegisteringCollector registers a new listener every time it starts, as its components do.
+type RegisteringCollector struct {
+	CrashingCollector
+	lm        *manager
+	listeners []*InvocationListener
+}
+
+func (c *RegisteringCollector) Start(ctx context.Context) error {
+	l := &InvocationListener{}
+	c.mu.Lock()
+	c.listeners = append(c.listeners, l)
+	c.mu.Unlock()
+	c.lm.AddListener(l)
+	return c.CrashingCollector.Start(ctx)
+}
+
+func (c *RegisteringCollector) listener(i int) *InvocationListener {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i >= len(c.listeners) {
+		return nil
+	}
+	return c.listeners[i]
+}
+
+func TestSuperviseCollectorReplaysInvocation(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	col := &RegisteringCollector{CrashingCollector: CrashingCollector{crash: 1}}
+	lm := manager{
+		collector:  col,
+		logger:     zaptest.NewLogger(t),
+		cancel:     cancel,
+		supervisor: supervisor{policy: collector.RestartPolicyRestart, maxRestarts: 3},
+	}
+	col.lm = &lm
+	require.NoError(t, col.Start(ctx))
+	lm.notifyFunctionInvoked()
+	require.Equal(t, int32(1), col.listener(0).invoked.Load())
+
+	// The collector crashed during the invocation, the listeners of the restarted one are told it is in flight.
+	go lm.superviseCollector(ctx)
+	require.Eventually(t, func() bool { return lm.Restarts() == 1 }, time.Second, time.Millisecond)
+	restarted := col.listener(1)
+	require.NotNil(t, restarted)
+	require.Eventually(t, func() bool { return restarted.invoked.Load() == 1 }, time.Second, time.Millisecond)
+
+	lm.notifyFunctionFinished(ctx)
+	require.Equal(t, int32(1), restarted.finished.Load())
+	require.Zero(t, col.listener(0).finished.Load(), "listeners of the crashed collector are removed")
+}
@@ -0,0 +1,185 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queuedrain keeps the sending queues of exporters enabled and blocks the Lambda lifecycle until they are
+// empty, so that no data is left in a queue when the environment is frozen or shut down.
+package queuedrain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/xexporter"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+)
+
+const (
+	// queueSizeMetric is reported by the exporter helper for each exporter with a sending queue. The size only
+	// decreases once an export, including its retries, has completed.
+	queueSizeMetric = "otelcol_exporter_queue_size"
+
+	defaultPollInterval = 10 * time.Millisecond
+)
+
+// Drainer discovers the sending queues of the exporters created by the collector and waits for them to be drained.
+//
+// The queues are discovered by wrapping the meter provider of each exporter, so that its queue size is also reported to
+// a meter provider read by the Drainer.
+type Drainer struct {
+	logger       *zap.Logger
+	timeout      time.Duration
+	pollInterval time.Duration
+
+	lock    sync.Mutex
+	tracked []tracked
+}
+
+// tracked is the meter provider an exporter reports its queue size to, and the reader of the Drainer.
+type tracked struct {
+	provider *sdkmetric.MeterProvider
+	reader   *sdkmetric.ManualReader
+}
+
+// New returns a Drainer that waits at most timeout for the queues to be drained.
+func New(logger *zap.Logger, timeout time.Duration) *Drainer {
+	return &Drainer{
+		logger:       logger.Named("queuedrain"),
+		timeout:      timeout,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// WrapExporters returns exporter factories whose exporters have their sending queue tracked by the Drainer.
+func (d *Drainer) WrapExporters(factories map[component.Type]exporter.Factory) map[component.Type]exporter.Factory {
+	wrapped := make(map[component.Type]exporter.Factory, len(factories))
+	for t, f := range factories {
+		wrapped[t] = d.wrapExporter(f)
+	}
+	return wrapped
+}
+
+func (d *Drainer) wrapExporter(f exporter.Factory) exporter.Factory {
+	var opts []xexporter.FactoryOption
+	if sl := f.TracesStability(); sl != component.StabilityLevelUndefined {
+		opts = append(opts, xexporter.WithTraces(func(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Traces, error) {
+			return f.CreateTraces(ctx, d.track(set), cfg)
+		}, sl))
+	}
+	if sl := f.MetricsStability(); sl != component.StabilityLevelUndefined {
+		opts = append(opts, xexporter.WithMetrics(func(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Metrics, error) {
+			return f.CreateMetrics(ctx, d.track(set), cfg)
+		}, sl))
+	}
+	if sl := f.LogsStability(); sl != component.StabilityLevelUndefined {
+		opts = append(opts, xexporter.WithLogs(func(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Logs, error) {
+			return f.CreateLogs(ctx, d.track(set), cfg)
+		}, sl))
+	}
+	if xf, ok := f.(xexporter.Factory); ok {
+		if sl := xf.ProfilesStability(); sl != component.StabilityLevelUndefined {
+			opts = append(opts, xexporter.WithProfiles(func(ctx context.Context, set exporter.Settings, cfg component.Config) (xexporter.Profiles, error) {
+				return xf.CreateProfiles(ctx, d.track(set), cfg)
+			}, sl))
+		}
+	}
+	return xexporter.NewFactory(f.Type(), f.CreateDefaultConfig, opts...)
+}
+
+// track wraps the meter provider of the exporter settings, so that the queue size is also reported to one read by the
+// Drainer.
+func (d *Drainer) track(set exporter.Settings) exporter.Settings {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	set.TelemetrySettings.MeterProvider = trackingMeterProvider{
+		MeterProvider: set.TelemetrySettings.MeterProvider,
+		tracked:       provider,
+	}
+
+	d.lock.Lock()
+	d.tracked = append(d.tracked, tracked{provider: provider, reader: reader})
+	d.lock.Unlock()
+
+	return set
+}
+
+// Reset stops tracking the sending queues of the exporters created so far. It is called before the collector is
+// started again, such as after a crash or a configuration reload, as the exporters of the previous collector are gone.
+func (d *Drainer) Reset(ctx context.Context) {
+	d.lock.Lock()
+	previous := d.tracked
+	d.tracked = nil
+	d.lock.Unlock()
+
+	for _, t := range previous {
+		if err := t.provider.Shutdown(ctx); err != nil {
+			d.logger.Debug("failed to shut down the exporter queue tracking", zap.Error(err))
+		}
+	}
+}
+
+// QueueSize returns the number of items in all tracked sending queues.
+func (d *Drainer) QueueSize(ctx context.Context) int64 {
+	d.lock.Lock()
+	current := d.tracked
+	d.lock.Unlock()
+
+	var size int64
+	for _, t := range current {
+		var rm metricdata.ResourceMetrics
+		if err := t.reader.Collect(ctx, &rm); err != nil {
+			d.logger.Debug("failed to collect exporter queue size", zap.Error(err))
+			continue
+		}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name != queueSizeMetric {
+					continue
+				}
+				if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok {
+					for _, dp := range gauge.DataPoints {
+						size += dp.Value
+					}
+				}
+			}
+		}
+	}
+	return size
+}
+
+// Drain blocks until all tracked sending queues are empty, the timeout has passed or ctx is done, whichever comes
+// first.
+func (d *Drainer) Drain(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	start := time.Now()
+	for {
+		size := d.QueueSize(context.WithoutCancel(ctx))
+		if size == 0 {
+			d.logger.Debug("exporter queues drained", zap.Duration("duration", time.Since(start)))
+			return
+		}
+		select {
+		case <-ctx.Done():
+			d.logger.Warn("timed out draining exporter queues", zap.Int64("remaining", size), zap.Duration("timeout", d.timeout), zap.Error(ctx.Err()))
+			return
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
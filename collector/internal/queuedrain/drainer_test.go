@@ -0,0 +1,160 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queuedrain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap/zaptest"
+)
+
+var testType = component.MustNewType("test")
+
+type testConfig struct{}
+
+func newBlockingFactory(release chan struct{}) exporter.Factory {
+	return exporter.NewFactory(
+		testType,
+		func() component.Config { return &testConfig{} },
+		exporter.WithTraces(func(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Traces, error) {
+			return exporterhelper.NewTraces(ctx, set, cfg, func(context.Context, ptrace.Traces) error {
+				<-release
+				return nil
+			}, exporterhelper.WithQueue(exporterhelper.NewDefaultQueueConfig()))
+		}, component.StabilityLevelDevelopment),
+	)
+}
+
+func TestWrapExporters(t *testing.T) {
+	d := New(zaptest.NewLogger(t), time.Second)
+	factories := d.WrapExporters(map[component.Type]exporter.Factory{testType: newBlockingFactory(make(chan struct{}))})
+
+	f := factories[testType]
+	require.Equal(t, testType, f.Type())
+	require.Equal(t, component.StabilityLevelDevelopment, f.TracesStability())
+	require.Equal(t, component.StabilityLevelUndefined, f.MetricsStability())
+	_, err := f.CreateMetrics(context.Background(), exportertest.NewNopSettings(testType), f.CreateDefaultConfig())
+	require.Error(t, err)
+}
+
+func TestDrain(t *testing.T) {
+	release := make(chan struct{})
+	d := New(zaptest.NewLogger(t), 50*time.Millisecond)
+	f := d.WrapExporters(map[component.Type]exporter.Factory{testType: newBlockingFactory(release)})[testType]
+
+	exp, err := f.CreateTraces(context.Background(), exportertest.NewNopSettings(testType), f.CreateDefaultConfig())
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), componenttest.NewNopHost()))
+	require.Equal(t, int64(0), d.QueueSize(context.Background()))
+
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	require.NoError(t, exp.ConsumeTraces(context.Background(), td))
+	require.Positive(t, d.QueueSize(context.Background()))
+
+	// The export is blocked, so draining gives up once the timeout has passed.
+	start := time.Now()
+	d.Drain(context.Background())
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	require.Positive(t, d.QueueSize(context.Background()))
+
+	// Draining also gives up once the deadline of the caller has passed, if it comes first.
+	d.timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	d.Drain(ctx)
+	require.Less(t, time.Since(start), time.Minute)
+	require.Positive(t, d.QueueSize(context.Background()))
+
+	close(release)
+	d.Drain(context.Background())
+	require.Equal(t, int64(0), d.QueueSize(context.Background()))
+
+	require.NoError(t, exp.Shutdown(context.Background()))
+}
+
+func TestTrackKeepsExporterTelemetry(t *testing.T) {
+	release := make(chan struct{})
+	d := New(zaptest.NewLogger(t), time.Second)
+	f := d.WrapExporters(map[component.Type]exporter.Factory{testType: newBlockingFactory(release)})[testType]
+
+	reader := sdkmetric.NewManualReader()
+	set := exportertest.NewNopSettings(testType)
+	set.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	exp, err := f.CreateTraces(context.Background(), set, f.CreateDefaultConfig())
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() {
+		close(release)
+		require.NoError(t, exp.Shutdown(context.Background()))
+	}()
+
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	require.NoError(t, exp.ConsumeTraces(context.Background(), td))
+	require.Positive(t, d.QueueSize(context.Background()))
+
+	// The collector's own telemetry still gets the metrics of the exporter, including the queue size.
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	require.Contains(t, metrics, "otelcol_exporter_queue_capacity")
+	require.Contains(t, metrics, queueSizeMetric)
+	gauge, ok := metrics[queueSizeMetric].(metricdata.Gauge[int64])
+	require.True(t, ok)
+	require.Len(t, gauge.DataPoints, 1)
+	require.Positive(t, gauge.DataPoints[0].Value)
+}
+
+func TestReset(t *testing.T) {
+	release := make(chan struct{})
+	d := New(zaptest.NewLogger(t), time.Second)
+	f := d.WrapExporters(map[component.Type]exporter.Factory{testType: newBlockingFactory(release)})[testType]
+
+	exp, err := f.CreateTraces(context.Background(), exportertest.NewNopSettings(testType), f.CreateDefaultConfig())
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() {
+		close(release)
+		require.NoError(t, exp.Shutdown(context.Background()))
+	}()
+
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	require.NoError(t, exp.ConsumeTraces(context.Background(), td))
+	require.Positive(t, d.QueueSize(context.Background()))
+
+	// Once the collector is started again, the exporters of the previous one are no longer tracked.
+	d.Reset(context.Background())
+	require.Equal(t, int64(0), d.QueueSize(context.Background()))
+	require.Empty(t, d.tracked)
+}
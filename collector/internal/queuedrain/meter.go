@@ -0,0 +1,128 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queuedrain
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+)
+
+// trackingMeterProvider is the meter provider of an exporter. Its meters create the instruments of the exporter with
+// the meter provider of the collector, and the queue size gauge with the meter provider read by the Drainer as well,
+// so that the internal metrics of exporters are still reported by the collector's own telemetry.
+type trackingMeterProvider struct {
+	metric.MeterProvider
+	tracked metric.MeterProvider
+}
+
+func (p trackingMeterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return trackingMeter{
+		Meter:   p.MeterProvider.Meter(name, opts...),
+		tracked: p.tracked.Meter(name, opts...),
+	}
+}
+
+type trackingMeter struct {
+	metric.Meter
+	tracked metric.Meter
+}
+
+// queueSizeGauge is the queue size gauge of both meter providers.
+type queueSizeGauge struct {
+	metric.Int64ObservableGauge
+	tracked metric.Int64ObservableGauge
+}
+
+func (m trackingMeter) Int64ObservableGauge(name string, opts ...metric.Int64ObservableGaugeOption) (metric.Int64ObservableGauge, error) {
+	gauge, err := m.Meter.Int64ObservableGauge(name, opts...)
+	if err != nil || name != queueSizeMetric {
+		return gauge, err
+	}
+	tracked, err := m.tracked.Int64ObservableGauge(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &queueSizeGauge{Int64ObservableGauge: gauge, tracked: tracked}, nil
+}
+
+// RegisterCallback registers f with the meter provider of the collector and, if it observes the queue size, with the
+// one read by the Drainer, which only gets the queue size observations.
+func (m trackingMeter) RegisterCallback(f metric.Callback, instruments ...metric.Observable) (metric.Registration, error) {
+	var gauge *queueSizeGauge
+	unwrapped := make([]metric.Observable, len(instruments))
+	for i, inst := range instruments {
+		unwrapped[i] = inst
+		if g, ok := inst.(*queueSizeGauge); ok {
+			gauge = g
+			unwrapped[i] = g.Int64ObservableGauge
+		}
+	}
+	reg, err := m.Meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		return f(ctx, unwrappingObserver{Observer: o})
+	}, unwrapped...)
+	if err != nil || gauge == nil {
+		return reg, err
+	}
+	trackedReg, err := m.tracked.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		return f(ctx, trackedObserver{Observer: o})
+	}, gauge.tracked)
+	if err != nil {
+		_ = reg.Unregister()
+		return nil, err
+	}
+	return &registrations{regs: []metric.Registration{reg, trackedReg}}, nil
+}
+
+// unwrappingObserver observes the queue size with the gauge of the collector's meter provider.
+type unwrappingObserver struct {
+	metric.Observer
+}
+
+func (o unwrappingObserver) ObserveInt64(obsrv metric.Int64Observable, value int64, opts ...metric.ObserveOption) {
+	if g, ok := obsrv.(*queueSizeGauge); ok {
+		obsrv = g.Int64ObservableGauge
+	}
+	o.Observer.ObserveInt64(obsrv, value, opts...)
+}
+
+// trackedObserver only observes the queue size, with the gauge of the meter provider read by the Drainer.
+type trackedObserver struct {
+	metric.Observer
+}
+
+func (o trackedObserver) ObserveFloat64(metric.Float64Observable, float64, ...metric.ObserveOption) {}
+
+func (o trackedObserver) ObserveInt64(obsrv metric.Int64Observable, value int64, opts ...metric.ObserveOption) {
+	if g, ok := obsrv.(*queueSizeGauge); ok {
+		o.Observer.ObserveInt64(g.tracked, value, opts...)
+	}
+}
+
+// registrations unregisters a callback from both meter providers.
+type registrations struct {
+	embedded.Registration
+	regs []metric.Registration
+}
+
+func (r *registrations) Unregister() error {
+	var err error
+	for _, reg := range r.regs {
+		err = errors.Join(err, reg.Unregister())
+	}
+	return err
+}
@@ -24,16 +24,17 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/golang-collections/go-datastructures/queue"
 	"go.uber.org/zap"
 )
 
 const (
-	initialQueueSize = 5
-	maxRetries       = 5
+	maxRetries = 5
+	// maxPendingResults bounds the number of runtimeDone results kept for invocations no one has waited for yet.
+	maxPendingResults = 100
 	// Define ephemeral port range (typical range is 49152-65535)
 	minPort = 49152
 	maxPort = 65535
@@ -44,19 +45,32 @@ func getRandomPort() string {
 	return fmt.Sprintf("%d", rand.Intn(maxPort-minPort)+minPort)
 }
 
+// errListenerClosed is returned to the callers still waiting for an invocation when the listener is shut down.
+var errListenerClosed = errors.New("telemetry API listener closed")
+
 // Listener is used to listen to the Telemetry API
 type Listener struct {
 	httpServer *http.Server
 	logger     *zap.Logger
-	// queue is a synchronous queue and is used to put the received log events to be dispatched later
-	queue *queue.Queue
+
+	mu sync.Mutex
+	// waiters are the channels of the callers waiting for the runtimeDone event of an invocation.
+	waiters map[string]chan RuntimeDone
+	// results are the runtimeDone events received before anyone waited for them, pending holds their request IDs
+	// from oldest to newest.
+	results   map[string]RuntimeDone
+	pending   []string
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 func NewListener(logger *zap.Logger) *Listener {
 	return &Listener{
 		httpServer: nil,
 		logger:     logger.Named("telemetryAPI.Listener"),
-		queue:      queue.New(initialQueueSize),
+		waiters:    make(map[string]chan RuntimeDone),
+		results:    make(map[string]RuntimeDone),
+		closed:     make(chan struct{}),
 	}
 }
 
@@ -98,8 +112,9 @@ func (s *Listener) Start() (string, error) {
 		return "", fmt.Errorf("failed to find available port: %w", err)
 	}
 	s.logger.Info("Listening for requests", zap.String("address", address))
-	s.httpServer = &http.Server{Addr: address}
-	http.HandleFunc("/", s.httpHandler)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.httpHandler)
+	s.httpServer = &http.Server{Addr: address, Handler: mux}
 	go func() {
 		err := s.httpServer.Serve(listener)
 		if err != http.ErrServerClosed {
@@ -113,8 +128,8 @@ func (s *Listener) Start() (string, error) {
 }
 
 // httpHandler handles the requests coming from the Telemetry API.
-// Everytime Telemetry API sends log events, this function will read them from the response body
-// and put into a synchronous queue to be dispatched later.
+// Everytime Telemetry API sends events, this function will read them from the response body
+// and hand the platform.runtimeDone events to the callers waiting for them.
 // Logging or printing besides the error cases below is not recommended if you have subscribed to
 // receive extension logs. Otherwise, logging here will cause Telemetry API to send new logs for
 // the printed lines which may create an infinite loop.
@@ -125,22 +140,45 @@ func (s *Listener) httpHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse and put the log messages into the queue
 	var slice []Event
 	_ = json.Unmarshal(body, &slice)
 
 	for _, el := range slice {
-		if err := s.queue.Put(el); err != nil {
-			s.logger.Error("Failed to put event in queue", zap.Error(err))
+		if EventType(el.Type) != PlatformRuntimeDone {
+			continue
 		}
+		done := RuntimeDone{}
+		done.RequestID, _ = el.Record["requestId"].(string)
+		done.Status, _ = el.Record["status"].(string)
+		done.ErrorType, _ = el.Record["errorType"].(string)
+		s.complete(done)
 	}
 
-	s.logger.Debug("logEvents received", zap.Int("count", len(slice)), zap.Int64("queue_length", s.queue.Len()))
-	slice = nil
+	s.logger.Debug("events received", zap.Int("count", len(slice)))
+}
+
+// complete hands the result to the caller waiting for the invocation, or keeps it until one does.
+func (s *Listener) complete(done RuntimeDone) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if waiter, ok := s.waiters[done.RequestID]; ok {
+		delete(s.waiters, done.RequestID)
+		waiter <- done
+		return
+	}
+	if _, ok := s.results[done.RequestID]; !ok {
+		s.pending = append(s.pending, done.RequestID)
+	}
+	s.results[done.RequestID] = done
+	if len(s.pending) > maxPendingResults {
+		delete(s.results, s.pending[0])
+		s.pending = s.pending[1:]
+	}
 }
 
 // Shutdown the HTTP server listening for logs
 func (s *Listener) Shutdown() {
+	s.closeOnce.Do(func() { close(s.closed) })
 	if s.httpServer != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 		defer cancel()
@@ -153,33 +191,43 @@ func (s *Listener) Shutdown() {
 	}
 }
 
-func (s *Listener) Wait(ctx context.Context, reqID string) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			s.logger.Debug("looking for platform.runtimeDone event")
-			items, err := s.queue.Get(10)
-			if err != nil {
-				return fmt.Errorf("unable to get telemetry events from queue: %w", err)
-			}
-
-			for _, item := range items {
-				i, ok := item.(Event)
-				if !ok {
-					s.logger.Warn("non-Event found in queue", zap.Any("item", item))
-					continue
-				}
-				s.logger.Debug("Event processed", zap.Any("event", i))
-				if i.Type != "platform.runtimeDone" {
-					continue
-				}
-
-				if i.Record["requestId"] == reqID {
-					return nil
-				}
+// Wait blocks until the platform.runtimeDone event of the invocation is received, including when it was received
+// before Wait was called, and returns the outcome of the invocation.
+func (s *Listener) Wait(ctx context.Context, reqID string) (RuntimeDone, error) {
+	s.mu.Lock()
+	if done, ok := s.results[reqID]; ok {
+		delete(s.results, reqID)
+		for i, id := range s.pending {
+			if id == reqID {
+				s.pending = append(s.pending[:i], s.pending[i+1:]...)
+				break
 			}
 		}
+		s.mu.Unlock()
+		return done, nil
+	}
+	// The channel is buffered so that completing the invocation never blocks, even if the caller gave up.
+	waiter := make(chan RuntimeDone, 1)
+	s.waiters[reqID] = waiter
+	s.mu.Unlock()
+
+	s.logger.Debug("waiting for platform.runtimeDone event", zap.String("requestID", reqID))
+	select {
+	case done := <-waiter:
+		return done, nil
+	case <-ctx.Done():
+		s.removeWaiter(reqID, waiter)
+		return RuntimeDone{}, ctx.Err()
+	case <-s.closed:
+		s.removeWaiter(reqID, waiter)
+		return RuntimeDone{}, errListenerClosed
+	}
+}
+
+func (s *Listener) removeWaiter(reqID string, waiter chan RuntimeDone) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.waiters[reqID] == waiter {
+		delete(s.waiters, reqID)
 	}
 }
@@ -0,0 +1,96 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetryapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func postEvents(t *testing.T, l *Listener, body string) {
+	rec := httptest.NewRecorder()
+	l.httpHandler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestWaitEarlyArrival(t *testing.T) {
+	l := NewListener(zaptest.NewLogger(t))
+	postEvents(t, l, `[
+		{"type":"platform.start","record":{"requestId":"request-1"}},
+		{"type":"platform.runtimeDone","record":{"requestId":"request-1","status":"error","errorType":"Runtime.ExitError"}}
+	]`)
+
+	done, err := l.Wait(context.Background(), "request-1")
+	require.NoError(t, err)
+	require.Equal(t, RuntimeDone{RequestID: "request-1", Status: "error", ErrorType: "Runtime.ExitError"}, done)
+	require.Empty(t, l.results)
+	require.Empty(t, l.pending)
+}
+
+func TestWaitLateArrival(t *testing.T) {
+	l := NewListener(zaptest.NewLogger(t))
+	// A stale result for another invocation must not complete the wait.
+	postEvents(t, l, `[{"type":"platform.runtimeDone","record":{"requestId":"request-0","status":"success"}}]`)
+
+	type waitResult struct {
+		done RuntimeDone
+		err  error
+	}
+	result := make(chan waitResult)
+	go func() {
+		done, err := l.Wait(context.Background(), "request-1")
+		result <- waitResult{done: done, err: err}
+	}()
+	require.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.waiters["request-1"] != nil
+	}, time.Second, time.Millisecond)
+
+	postEvents(t, l, `[{"type":"platform.runtimeDone","record":{"requestId":"request-1","status":"success"}}]`)
+	res := <-result
+	require.NoError(t, res.err)
+	require.Equal(t, "success", res.done.Status)
+}
+
+func TestWaitCancelled(t *testing.T) {
+	l := NewListener(zaptest.NewLogger(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := l.Wait(ctx, "request-1")
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, l.waiters)
+
+	l.Shutdown()
+	_, err = l.Wait(context.Background(), "request-1")
+	require.ErrorIs(t, err, errListenerClosed)
+}
+
+func TestPendingResultsBounded(t *testing.T) {
+	l := NewListener(zaptest.NewLogger(t))
+	for i := 0; i <= maxPendingResults; i++ {
+		postEvents(t, l, fmt.Sprintf(`[{"type":"platform.runtimeDone","record":{"requestId":"request-%d"}}]`, i))
+	}
+	require.Len(t, l.results, maxPendingResults)
+	require.NotContains(t, l.results, "request-0")
+}
@@ -24,6 +24,8 @@ const (
 	PlatformInitStart EventType = Platform + ".initStart"
 	// PlatformInitRuntimeDone is used when function initialization ended.
 	PlatformInitRuntimeDone EventType = Platform + ".initRuntimeDone"
+	// PlatformRuntimeDone is used when the runtime completed an invocation.
+	PlatformRuntimeDone EventType = Platform + ".runtimeDone"
 	// Function is used to receive log events emitted by the function
 	Function EventType = "function"
 	// Extension is used is to receive log events emitted by the extension
@@ -91,3 +93,12 @@ type Event struct {
 	Type   string         `json:"type"`
 	Record map[string]any `json:"record"`
 }
+
+// RuntimeDone is the outcome of an invocation, as reported by a platform.runtimeDone event.
+type RuntimeDone struct {
+	RequestID string
+	// Status is "success", "failure", "error" or "timeout".
+	Status string
+	// ErrorType is reported when the invocation did not succeed, e.g. "Runtime.ExitError".
+	ErrorType string
+}
@@ -14,6 +14,8 @@
 
 package lambdalifecycle
 
+import "time"
+
 // Listener interface used to notify objects of Lambda lifecycle events.
 type Listener interface {
 	// FunctionInvoked is called after the extension receives a "Next" notification.
@@ -26,10 +28,97 @@ type Listener interface {
 	EnvironmentShutdown()
 }
 
+// ShutdownInfo describes why and when the environment is shut down.
+type ShutdownInfo struct {
+	// Reason is the shutdown reason reported by Lambda: "spindown", "timeout" or "failure".
+	Reason string
+	// Deadline is the time by which the extension must have exited. It is zero if Lambda did not report it.
+	Deadline time.Time
+}
+
+// ShutdownInfoListener is implemented by listeners that need the details of the environment shutdown.
+// EnvironmentShutdownWithInfo is called instead of EnvironmentShutdown for such listeners.
+type ShutdownInfoListener interface {
+	Listener
+	EnvironmentShutdownWithInfo(info ShutdownInfo)
+}
+
+// PendingReporter is implemented by listeners holding data that has not been exported yet.
+type PendingReporter interface {
+	// Pending returns the number of queued payloads that have not been exported yet.
+	Pending() int
+}
+
+// ListenerTiming is the time spent by a listener handling a lifecycle event.
+type ListenerTiming struct {
+	// Name identifies the listener, usually by its type.
+	Name string
+	// Duration is the time the listener took to return.
+	Duration time.Duration
+}
+
+// InvocationOverhead describes the time the extension added to an invocation after the function's runtime completed.
+type InvocationOverhead struct {
+	// RequestID is the ID of the invocation.
+	RequestID string
+	// RuntimeDone is when the extension was notified that the runtime completed the invocation.
+	RuntimeDone time.Time
+	// End is when the extension asked for the next event, allowing the environment to be frozen.
+	End time.Time
+	// Listeners is the time spent by each listener in FunctionFinished, in the order they were called.
+	Listeners []ListenerTiming
+}
+
+// InvocationOverheadListener is implemented by listeners reporting the time the extension added to invocations.
+// InvocationOverhead is called right before the extension asks for the next event.
+type InvocationOverheadListener interface {
+	Listener
+	InvocationOverhead(overhead InvocationOverhead)
+}
+
+// StartupPhase is a step of the extension startup.
+type StartupPhase struct {
+	// Name identifies the step, for example "collector.start".
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// ExtensionStartup describes the time the extension spent starting during the init phase of the environment.
+type ExtensionStartup struct {
+	// Start is when the extension started registering with the Extensions API.
+	Start time.Time
+	// End is when the collector was started, right before the extension asks for the first event.
+	End time.Time
+	// Phases are the steps of the startup, in the order they happened.
+	Phases []StartupPhase
+	// Degraded is the reason the extension runs in a degraded mode, such as "Extension.SubscribeFailed", or empty if
+	// it started normally.
+	Degraded string
+	// ConfigFallback is the error of the collector configuration if the extension fell back to its built-in
+	// configuration, or empty if it runs the configured one.
+	ConfigFallback string
+}
+
+// StartupListener is implemented by listeners reporting the extension startup.
+// ExtensionStarted is called once the collector has started, before the extension asks for the first event.
+type StartupListener interface {
+	Listener
+	ExtensionStarted(startup ExtensionStartup)
+}
+
 type Notifier interface {
 	AddListener(listener Listener)
 }
 
+// AccountIDNotifier is implemented by notifiers that know the AWS account ID of the function, as returned by Lambda
+// when the extension registered.
+type AccountIDNotifier interface {
+	Notifier
+	// AccountID returns the AWS account ID of the function, or an empty string if Lambda did not return it.
+	AccountID() string
+}
+
 var (
 	notifier Notifier
 )
@@ -41,3 +130,11 @@ func SetNotifier(n Notifier) {
 func GetNotifier() Notifier {
 	return notifier
 }
+
+// AccountID returns the AWS account ID of the function known by the notifier, or an empty string if it is unknown.
+func AccountID() string {
+	if n, ok := notifier.(AccountIDNotifier); ok {
+		return n.AccountID()
+	}
+	return ""
+}
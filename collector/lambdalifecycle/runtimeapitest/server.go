@@ -0,0 +1,534 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runtimeapitest provides a fake Lambda Runtime API, so that extensions and components relying on the
+// Extensions API and the Telemetry API can be tested end to end outside of Lambda.
+//
+// The server serves a scripted sequence of events from /event/next and, once each event is served, pushes the
+// Telemetry API events scripted for it to the subscribed destinations. The events of the initialization are pushed
+// when /event/next is first called, once the extensions are initialized. Destinations on sandbox.localdomain or without
+// a host, as used by listeners when AWS_SAM_LOCAL is set, are reached on 127.0.0.1.
+package runtimeapitest // import "github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle/runtimeapitest"
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	extensionNameHeader       = "Lambda-Extension-Name"
+	extensionIdentifierHeader = "Lambda-Extension-Identifier"
+	extensionErrorTypeHeader  = "Lambda-Extension-Function-Error-Type"
+	extensionAcceptFeature    = "Lambda-Extension-Accept-Feature"
+
+	accountIDFeature = "accountId"
+
+	// timeFormat is the format of the times of Telemetry API events.
+	timeFormat = "2006-01-02T15:04:05.000Z"
+
+	// pushAttempts and pushRetryInterval bound the retries of a push refused by a listener that is still starting.
+	pushAttempts      = 20
+	pushRetryInterval = 50 * time.Millisecond
+)
+
+const (
+	// EventInvoke is the type of the events of invocations.
+	EventInvoke = "INVOKE"
+	// EventShutdown is the type of the event ending the environment.
+	EventShutdown = "SHUTDOWN"
+)
+
+// Function describes the function returned on registration. Empty fields are set to defaults.
+type Function struct {
+	Name      string
+	Version   string
+	Handler   string
+	AccountID string
+}
+
+// TelemetryEvent is an event of the Telemetry API.
+type TelemetryEvent struct {
+	Time   string `json:"time"`
+	Type   string `json:"type"`
+	Record any    `json:"record"`
+}
+
+// Event is an event served by /event/next, along with the Telemetry API events pushed once it is served.
+type Event struct {
+	Type string
+	// Timeout is the time left to the event once it is served, from which its deadline is computed.
+	Timeout            time.Duration
+	RequestID          string
+	InvokedFunctionArn string
+	ShutdownReason     string
+	Telemetry          []TelemetryEvent
+}
+
+// Script is what the server plays.
+type Script struct {
+	Function Function
+	// Init are the Telemetry API events of the initialization, such as platform.initStart. They are pushed before the
+	// first event is served.
+	Init []TelemetryEvent
+	// Events are served in order by /event/next. Once they are exhausted, /event/next blocks as in a frozen
+	// environment until the request is cancelled or the server is closed.
+	Events []Event
+}
+
+// Subscription is a Telemetry API subscription.
+type Subscription struct {
+	ExtensionID string
+	Types       []string
+	// URI is the destination the events are pushed to.
+	URI string
+}
+
+// ReportedError is an error reported to /init/error or /exit/error.
+type ReportedError struct {
+	ExtensionID string
+	// Type is the error type, from the Lambda-Extension-Function-Error-Type header.
+	Type string
+	// Message is the errorMessage of the body.
+	Message string
+}
+
+// delivery is a batch of Telemetry API events pushed to destinations. A delivery with only done set marks the point
+// at which all previous deliveries are complete.
+type delivery struct {
+	subscriptions []Subscription
+	events        []TelemetryEvent
+	done          chan struct{}
+}
+
+// Server is a fake Lambda Runtime API.
+type Server struct {
+	// Addr is the host and port of the server, the value of the AWS_LAMBDA_RUNTIME_API environment variable.
+	Addr string
+
+	server     *httptest.Server
+	client     *http.Client
+	function   Function
+	init       []TelemetryEvent
+	deliveries chan delivery
+	delivered  chan struct{}
+	closed     chan struct{}
+	closeOnce  sync.Once
+
+	mu             sync.Mutex
+	initPushed     bool
+	events         []Event
+	extensions     map[string]string
+	subscriptions  []Subscription
+	initErrors     []ReportedError
+	exitErrors     []ReportedError
+	deliveryErrors []error
+}
+
+// NewServer starts a server playing script. It is closed with Close.
+func NewServer(script Script) *Server {
+	s := &Server{
+		client:     &http.Client{Timeout: 5 * time.Second},
+		function:   withDefaults(script.Function),
+		init:       script.Init,
+		deliveries: make(chan delivery),
+		delivered:  make(chan struct{}),
+		closed:     make(chan struct{}),
+		events:     script.Events,
+		extensions: make(map[string]string),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /2020-01-01/extension/register", s.handleRegister)
+	mux.HandleFunc("GET /2020-01-01/extension/event/next", s.handleNext)
+	mux.HandleFunc("POST /2020-01-01/extension/init/error", s.handleError(&s.initErrors))
+	mux.HandleFunc("POST /2020-01-01/extension/exit/error", s.handleError(&s.exitErrors))
+	mux.HandleFunc("PUT /2022-07-01/telemetry", s.handleSubscribe)
+	s.server = httptest.NewServer(mux)
+	s.Addr = s.server.Listener.Addr().String()
+	go s.deliver()
+	return s
+}
+
+func withDefaults(f Function) Function {
+	if f.Name == "" {
+		f.Name = "function"
+	}
+	if f.Version == "" {
+		f.Version = "$LATEST"
+	}
+	if f.Handler == "" {
+		f.Handler = "index.handler"
+	}
+	if f.AccountID == "" {
+		f.AccountID = "123456789012"
+	}
+	return f
+}
+
+// Close unblocks the pending /event/next requests, shuts the server down and waits for the deliveries in progress.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.server.Close()
+		close(s.deliveries)
+		<-s.delivered
+	})
+}
+
+// Register registers an extension as if it had called /register and returns its identifier, for components that
+// are given the identifier of the extension they run in.
+func (s *Server) Register(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("%08d-0000-4000-8000-000000000000", len(s.extensions)+1)
+	s.extensions[id] = name
+	return id
+}
+
+// Subscriptions returns the Telemetry API subscriptions, in order.
+func (s *Server) Subscriptions() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.subscriptions)
+}
+
+// InitErrors returns the errors reported to /init/error.
+func (s *Server) InitErrors() []ReportedError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.initErrors)
+}
+
+// ExitErrors returns the errors reported to /exit/error.
+func (s *Server) ExitErrors() []ReportedError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.exitErrors)
+}
+
+// DeliveryErrors returns the errors pushing Telemetry API events to destinations.
+func (s *Server) DeliveryErrors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.deliveryErrors)
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	name := r.Header.Get(extensionNameHeader)
+	if name == "" {
+		http.Error(w, "missing "+extensionNameHeader, http.StatusForbidden)
+		return
+	}
+	id := s.Register(name)
+	res := map[string]string{
+		"functionName":    s.function.Name,
+		"functionVersion": s.function.Version,
+		"handler":         s.function.Handler,
+	}
+	if slices.Contains(strings.Split(r.Header.Get(extensionAcceptFeature), ","), accountIDFeature) {
+		res["accountId"] = s.function.AccountID
+	}
+	w.Header().Set(extensionIdentifierHeader, id)
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleNext(w http.ResponseWriter, r *http.Request) {
+	if !s.registered(w, r) {
+		return
+	}
+	s.mu.Lock()
+	pushInit := !s.initPushed && len(s.init) > 0
+	s.initPushed = true
+	subscriptions := slices.Clone(s.subscriptions)
+	s.mu.Unlock()
+	if pushInit {
+		s.enqueue(delivery{subscriptions: subscriptions, events: s.init})
+	}
+	// The telemetry of the previous events is delivered before the next one is served, as the platform would.
+	if !s.flush(r) {
+		return
+	}
+
+	s.mu.Lock()
+	if len(s.events) == 0 {
+		s.mu.Unlock()
+		select {
+		case <-r.Context().Done():
+		case <-s.closed:
+		}
+		return
+	}
+	event := s.events[0]
+	s.events = s.events[1:]
+	subscriptions = slices.Clone(s.subscriptions)
+	s.mu.Unlock()
+
+	var deadlineMs int64
+	if event.Timeout > 0 {
+		deadlineMs = time.Now().Add(event.Timeout).UnixMilli()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"eventType":          event.Type,
+		"deadlineMs":         deadlineMs,
+		"requestId":          event.RequestID,
+		"invokedFunctionArn": event.InvokedFunctionArn,
+		"tracing":            map[string]string{"type": "X-Amzn-Trace-Id", "value": ""},
+		"shutdownReason":     event.ShutdownReason,
+	})
+	if len(event.Telemetry) > 0 {
+		s.enqueue(delivery{subscriptions: subscriptions, events: event.Telemetry})
+	}
+}
+
+func (s *Server) handleError(errs *[]ReportedError) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.registered(w, r) {
+			return
+		}
+		var body struct {
+			ErrorMessage string `json:"errorMessage"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		*errs = append(*errs, ReportedError{
+			ExtensionID: r.Header.Get(extensionIdentifierHeader),
+			Type:        r.Header.Get(extensionErrorTypeHeader),
+			Message:     body.ErrorMessage,
+		})
+		s.mu.Unlock()
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "OK"})
+	}
+}
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	if !s.registered(w, r) {
+		return
+	}
+	var req struct {
+		Types       []string `json:"types"`
+		Destination struct {
+			URI string `json:"URI"`
+		} `json:"destination"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := url.Parse(req.Destination.URI); err != nil || len(req.Types) == 0 {
+		http.Error(w, "invalid subscription", http.StatusBadRequest)
+		return
+	}
+	subscription := Subscription{
+		ExtensionID: r.Header.Get(extensionIdentifierHeader),
+		Types:       req.Types,
+		URI:         req.Destination.URI,
+	}
+	s.mu.Lock()
+	s.subscriptions = append(s.subscriptions, subscription)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
+
+// registered rejects the requests of extensions that did not register.
+func (s *Server) registered(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	_, ok := s.extensions[r.Header.Get(extensionIdentifierHeader)]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown extension identifier", http.StatusForbidden)
+	}
+	return ok
+}
+
+func (s *Server) enqueue(d delivery) {
+	select {
+	case s.deliveries <- d:
+	case <-s.closed:
+	}
+}
+
+// flush waits for the deliveries enqueued so far. It returns false if the request was cancelled in the meantime.
+func (s *Server) flush(r *http.Request) bool {
+	done := make(chan struct{})
+	s.enqueue(delivery{done: done})
+	select {
+	case <-done:
+		return true
+	case <-r.Context().Done():
+		return false
+	case <-s.closed:
+		return false
+	}
+}
+
+// deliver pushes the deliveries in order, so that each destination receives the events in the order of the script.
+func (s *Server) deliver() {
+	defer close(s.delivered)
+	for d := range s.deliveries {
+		if d.done != nil {
+			close(d.done)
+			continue
+		}
+		for _, subscription := range d.subscriptions {
+			var events []TelemetryEvent
+			for _, event := range d.events {
+				category, _, _ := strings.Cut(event.Type, ".")
+				if slices.Contains(subscription.Types, category) {
+					events = append(events, event)
+				}
+			}
+			if len(events) == 0 {
+				continue
+			}
+			if err := s.push(subscription.URI, events); err != nil {
+				s.mu.Lock()
+				s.deliveryErrors = append(s.deliveryErrors, err)
+				s.mu.Unlock()
+			}
+		}
+	}
+}
+
+func (s *Server) push(uri string, events []TelemetryEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err = s.post(localURI(uri), body)
+		if !errors.Is(err, syscall.ECONNREFUSED) || attempt == pushAttempts {
+			return err
+		}
+		select {
+		case <-time.After(pushRetryInterval):
+		case <-s.closed:
+			return err
+		}
+	}
+}
+
+func (s *Server) post(uri string, body []byte) error {
+	resp, err := s.client.Post(uri, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot push telemetry to %s: %w", uri, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot push telemetry to %s: %s", uri, resp.Status)
+	}
+	return nil
+}
+
+// localURI returns the address at which the destination can be reached outside of Lambda.
+func localURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	if host := u.Hostname(); host == "" || host == "sandbox.localdomain" {
+		u.Host = net.JoinHostPort("127.0.0.1", u.Port())
+	}
+	return u.String()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Invoke returns an invocation, along with the platform.start, function log, platform.runtimeDone and
+// platform.report events of a successful invocation.
+func Invoke(requestID string) Event {
+	start := time.Now()
+	end := start.Add(10 * time.Millisecond)
+	return Event{
+		Type:               EventInvoke,
+		Timeout:            3 * time.Second,
+		RequestID:          requestID,
+		InvokedFunctionArn: "arn:aws:lambda:us-east-1:123456789012:function:function",
+		Telemetry: []TelemetryEvent{
+			{Time: formatTime(start), Type: "platform.start", Record: map[string]any{"requestId": requestID, "version": "$LATEST"}},
+			FunctionLog(start, "handling "+requestID),
+			{Time: formatTime(end), Type: "platform.runtimeDone", Record: map[string]any{"requestId": requestID, "status": "success"}},
+			{Time: formatTime(end), Type: "platform.report", Record: map[string]any{
+				"requestId": requestID,
+				"status":    "success",
+				"metrics": map[string]any{
+					"durationMs":       10.0,
+					"billedDurationMs": 10,
+					"memorySizeMB":     128,
+					"maxMemoryUsedMB":  64,
+				},
+			}},
+		},
+	}
+}
+
+// Shutdown returns the event ending the environment for reason, "spindown", "timeout" or "failure".
+func Shutdown(reason string) Event {
+	return Event{
+		Type:           EventShutdown,
+		Timeout:        2 * time.Second,
+		ShutdownReason: reason,
+	}
+}
+
+// InitTelemetry returns the platform.initStart and platform.initRuntimeDone events of a successful initialization.
+func InitTelemetry() []TelemetryEvent {
+	start := time.Now()
+	return []TelemetryEvent{
+		{Time: formatTime(start), Type: "platform.initStart", Record: map[string]any{
+			"initializationType": "on-demand",
+			"phase":              "init",
+			"runtimeVersion":     "nodejs:20",
+		}},
+		{Time: formatTime(start.Add(100 * time.Millisecond)), Type: "platform.initRuntimeDone", Record: map[string]any{
+			"initializationType": "on-demand",
+			"phase":              "init",
+			"status":             "success",
+		}},
+	}
+}
+
+// FunctionLog returns a log line of the function.
+func FunctionLog(t time.Time, message string) TelemetryEvent {
+	return TelemetryEvent{Time: formatTime(t), Type: "function", Record: message}
+}
+
+// ExtensionLog returns a log line of an extension.
+func ExtensionLog(t time.Time, message string) TelemetryEvent {
+	return TelemetryEvent{Time: formatTime(t), Type: "extension", Record: message}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
@@ -38,13 +38,20 @@ var (
 
 func main() {
 	versionFlag := flag.Bool("v", false, "prints version information")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 	if *versionFlag {
 		fmt.Println(Version)
 		return
 	}
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(context.Background(), flag.Args(), os.Stdout, os.Stderr))
+	}
 
-	logger := initLogger()
+	logger := initLogger(os.Stdout)
 	logger.Info("Launching OpenTelemetry Lambda extension", zap.String("version", Version))
 
 	ctx, lm := lifecycle.NewManager(context.Background(), logger, Version)
@@ -56,7 +63,7 @@ func main() {
 	logger.Info("done", zap.Error(lm.Run(ctx)))
 }
 
-func initLogger() *zap.Logger {
+func initLogger(w zapcore.WriteSyncer) *zap.Logger {
 	lvl := zap.NewAtomicLevelAt(zapcore.InfoLevel)
 	envLvl := os.Getenv("OPENTELEMETRY_EXTENSION_LOG_LEVEL")
 	// When not set, Getenv returns empty string
@@ -69,7 +76,7 @@ func initLogger() *zap.Logger {
 		}
 	}
 
-	l := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), os.Stdout, lvl))
+	l := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), w, lvl))
 
 	if err != nil && envLvl != "" {
 		l.Warn("unable to parse log level from environment", zap.Error(err))
@@ -34,14 +34,22 @@ type faasExecution struct {
 
 type coldstartProcessor struct {
 	coldstartSpan *ptrace.Span
-	faasExecution *faasExecution
-	logger        *zap.Logger
-	nextConsumer  consumer.Traces
-	reported      bool // whether the cold start has already been reported
+	// coldstartChildren are the other spans of the coldstart trace, such as the extension startup spans. They are held
+	// and moved to the execution trace along with the coldstart span.
+	coldstartChildren ptrace.SpanSlice
+	// coldstartTraceID is the trace ID the coldstart span was reported with and executionTraceID the one it was
+	// moved to, so that children reported later can follow it.
+	coldstartTraceID pcommon.TraceID
+	executionTraceID pcommon.TraceID
+	faasExecution    *faasExecution
+	logger           *zap.Logger
+	nextConsumer     consumer.Traces
+	reported         bool // whether the cold start has already been reported
+	done             bool // whether a warm invocation followed the cold start, after which spans are passed through
 }
 
 func (p *coldstartProcessor) processTraces(ctx context.Context, td ptrace.Traces) (ptrace.Traces, error) {
-	if p.reported {
+	if p.done || (p.reported && p.coldstartTraceID.IsEmpty()) {
 		return td, nil
 	}
 	td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
@@ -50,9 +58,16 @@ func (p *coldstartProcessor) processTraces(ctx context.Context, td ptrace.Traces
 			scope := ss.Scope()
 			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
 				if p.reported {
+					if p.isColdstartChild(span) {
+						span.SetTraceID(p.executionTraceID)
+					} else if isExecution(span) && span.TraceID() != p.executionTraceID {
+						// The children of the coldstart trace are reported by the first invocation at the latest.
+						p.done = true
+					}
 					return false
 				}
 				if attr, ok := span.Attributes().Get(semconv.AttributeFaaSColdstart); ok && attr.Bool() {
+					p.coldstartTraceID = span.TraceID()
 					if p.faasExecution == nil {
 						sp := ptrace.NewSpan()
 						p.coldstartSpan = &sp
@@ -63,11 +78,16 @@ func (p *coldstartProcessor) processTraces(ctx context.Context, td ptrace.Traces
 						p.faasExecution.resource.CopyTo(resource)
 						span.SetParentSpanID(p.faasExecution.span.ParentSpanID())
 						span.SetTraceID(p.faasExecution.span.TraceID())
+						p.executionTraceID = span.TraceID()
 						p.reported = true
 						return false
 					}
 				}
-				if _, ok := span.Attributes().Get(semconv.AttributeFaaSExecution); ok {
+				if p.coldstartSpan != nil && p.isColdstartChild(span) {
+					span.CopyTo(p.coldstartChildren.AppendEmpty())
+					return true
+				}
+				if isExecution(span) {
 					if p.coldstartSpan == nil {
 						p.faasExecution = &faasExecution{
 							span:     ptrace.NewSpan(),
@@ -83,8 +103,15 @@ func (p *coldstartProcessor) processTraces(ctx context.Context, td ptrace.Traces
 						p.coldstartSpan.CopyTo(s)
 						s.SetParentSpanID(span.ParentSpanID())
 						s.SetTraceID(span.TraceID())
+						for i := 0; i < p.coldstartChildren.Len(); i++ {
+							c := ss.Spans().AppendEmpty()
+							p.coldstartChildren.At(i).CopyTo(c)
+							c.SetTraceID(span.TraceID())
+						}
+						p.executionTraceID = span.TraceID()
 						p.reported = true
 						p.coldstartSpan = nil
+						p.coldstartChildren = ptrace.NewSpanSlice()
 					}
 				}
 				return false
@@ -100,13 +127,26 @@ func (p *coldstartProcessor) processTraces(ctx context.Context, td ptrace.Traces
 	return td, nil
 }
 
+// isColdstartChild returns whether the span belongs to the trace of the coldstart span. Coldstart spans without a trace
+// ID have no children.
+func (p *coldstartProcessor) isColdstartChild(span ptrace.Span) bool {
+	return !p.coldstartTraceID.IsEmpty() && span.TraceID() == p.coldstartTraceID
+}
+
+// isExecution returns whether the span is the execution span of an invocation.
+func isExecution(span ptrace.Span) bool {
+	_, ok := span.Attributes().Get(semconv.AttributeFaaSExecution)
+	return ok
+}
+
 func newColdstartProcessor(
 	cfg *Config,
 	next consumer.Traces,
 	set processor.Settings,
 ) (*coldstartProcessor, error) {
 	return &coldstartProcessor{
-		nextConsumer: next,
-		logger:       set.Logger,
+		nextConsumer:      next,
+		logger:            set.Logger,
+		coldstartChildren: ptrace.NewSpanSlice(),
 	}, nil
 }
@@ -209,6 +209,57 @@ func TestMultipleProcessTraces(t *testing.T) {
 	require.True(t, c.reported)
 }
 
+func TestColdstartChildren(t *testing.T) {
+	c, err := newColdstartProcessor(
+		nil,
+		nil,
+		processortest.NewNopSettings(Type),
+	)
+	require.NoError(t, err)
+	coldstartTraceID := getTraceID()
+	executionTraceID := getTraceID()
+
+	input := ptrace.NewTraces()
+	spans := input.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	initializationSpan(spans.AppendEmpty(), coldstartTraceID)
+	spans.AppendEmpty().SetTraceID(coldstartTraceID)
+	output, err := c.processTraces(context.Background(), input)
+	require.Error(t, err)
+	require.Equal(t, 0, output.SpanCount())
+
+	input = ptrace.NewTraces()
+	addExecutionSpan(input, executionTraceID)
+	output, err = c.processTraces(context.Background(), input)
+	require.NoError(t, err)
+	require.Equal(t, 3, output.SpanCount())
+	spans = output.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	for i := 0; i < spans.Len(); i++ {
+		require.Equal(t, executionTraceID, spans.At(i).TraceID())
+	}
+	require.True(t, c.reported)
+
+	// Children reported after the cold start follow it to the execution trace.
+	input = ptrace.NewTraces()
+	input.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetTraceID(coldstartTraceID)
+	output, err = c.processTraces(context.Background(), input)
+	require.NoError(t, err)
+	require.Equal(t, executionTraceID, output.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).TraceID())
+	require.False(t, c.done)
+
+	// Once a warm invocation is reported, spans are passed through.
+	input = ptrace.NewTraces()
+	addExecutionSpan(input, getTraceID())
+	_, err = c.processTraces(context.Background(), input)
+	require.NoError(t, err)
+	require.True(t, c.done)
+
+	input = ptrace.NewTraces()
+	input.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetTraceID(coldstartTraceID)
+	output, err = c.processTraces(context.Background(), input)
+	require.NoError(t, err)
+	require.Equal(t, coldstartTraceID, output.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).TraceID())
+}
+
 func getTraceID() pcommon.TraceID {
 	var rngSeed int64
 	_ = binary.Read(crand.Reader, binary.LittleEndian, &rngSeed)
@@ -0,0 +1,204 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoupleprocessor // import "github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const bufferFileExt = ".pb"
+
+var payloadTooLargeError = errors.New("payload is larger than the retry buffer")
+
+// claimedDirs holds the directories of the retry buffers in use in the process. Processors of several pipelines
+// sharing a directory would replay and delete each other's entries, so a directory has a single owner.
+var (
+	claimedDirsLock sync.Mutex
+	claimedDirs     = map[string]bool{}
+)
+
+// retryBuffer persists payloads that failed to export to the local file system, so that they can be replayed later
+// in the lifetime of the execution environment. The buffer is bounded both in size and in the age of its entries.
+type retryBuffer struct {
+	logger  *zap.Logger
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+
+	lock    sync.Mutex
+	seq     uint64
+	claimed bool
+}
+
+type bufferEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func newRetryBuffer(cfg RetryBufferConfig, name string, logger *zap.Logger) *retryBuffer {
+	return &retryBuffer{
+		logger:  logger,
+		dir:     filepath.Join(cfg.Directory, strings.ReplaceAll(name, "/", "_")),
+		maxSize: int64(cfg.MaxSizeMiB) << 20,
+		maxAge:  cfg.MaxAge,
+	}
+}
+
+// claim makes the buffer the owner of its directory. It fails if another buffer of the process owns it.
+func (b *retryBuffer) claim() error {
+	claimedDirsLock.Lock()
+	defer claimedDirsLock.Unlock()
+	if claimedDirs[b.dir] {
+		return fmt.Errorf("retry buffer directory %s is already used by another pipeline of the same signal, give the decouple processor of each pipeline a distinct name, such as decouple/<pipeline>", b.dir)
+	}
+	claimedDirs[b.dir] = true
+	b.claimed = true
+	return nil
+}
+
+// release gives up the ownership of the directory, if the buffer claimed it.
+func (b *retryBuffer) release() {
+	claimedDirsLock.Lock()
+	defer claimedDirsLock.Unlock()
+	if b.claimed {
+		delete(claimedDirs, b.dir)
+		b.claimed = false
+	}
+}
+
+// store writes the payload to the buffer, evicting the oldest entries if required to stay within the size limit.
+func (b *retryBuffer) store(payload []byte) error {
+	size := int64(len(payload))
+	if size > b.maxSize {
+		return payloadTooLargeError
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := os.MkdirAll(b.dir, 0o700); err != nil {
+		return err
+	}
+	entries, err := b.list()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	for len(entries) > 0 && total+size > b.maxSize {
+		b.logger.Warn("retry buffer is full, dropping oldest entry", zap.String("path", entries[0].path))
+		b.remove(entries[0])
+		total -= entries[0].size
+		entries = entries[1:]
+	}
+
+	b.seq++
+	// The file name starts with the creation time, so that sorting the entries by name yields the order in which
+	// they were stored.
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), b.seq, bufferFileExt)
+	tmp := filepath.Join(b.dir, name+".tmp")
+	if err := os.WriteFile(tmp, payload, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(b.dir, name))
+}
+
+// entries returns the stored entries, oldest first. Entries older than the maximum age are removed.
+func (b *retryBuffer) entries() ([]bufferEntry, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entries, err := b.list()
+	if err != nil {
+		return nil, err
+	}
+
+	valid := entries[:0]
+	for _, e := range entries {
+		if time.Since(e.modTime) > b.maxAge {
+			b.logger.Warn("dropping expired entry from retry buffer", zap.String("path", e.path))
+			b.remove(e)
+			continue
+		}
+		valid = append(valid, e)
+	}
+	return valid, nil
+}
+
+// count returns the number of stored entries, including expired ones that have not been removed yet.
+func (b *retryBuffer) count() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entries, err := b.list()
+	if err != nil {
+		b.logger.Debug("failed to count the entries of the retry buffer", zap.Error(err))
+		return 0
+	}
+	return len(entries)
+}
+
+func (b *retryBuffer) load(e bufferEntry) ([]byte, error) {
+	return os.ReadFile(e.path)
+}
+
+func (b *retryBuffer) remove(e bufferEntry) {
+	if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+		b.logger.Warn("failed to remove entry from retry buffer", zap.String("path", e.path), zap.Error(err))
+	}
+}
+
+func (b *retryBuffer) list() ([]bufferEntry, error) {
+	files, err := os.ReadDir(b.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []bufferEntry
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != bufferFileExt {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, bufferEntry{
+			path:    filepath.Join(b.dir, f.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].path < entries[j].path
+	})
+	return entries, nil
+}
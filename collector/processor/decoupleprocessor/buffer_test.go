@@ -0,0 +1,81 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoupleprocessor // import "github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor"
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRetryBufferStore(t *testing.T) {
+	b := newRetryBuffer(RetryBufferConfig{Directory: t.TempDir(), MaxSizeMiB: 1, MaxAge: time.Hour}, "decouple/test_traces", zap.NewNop())
+
+	require.NoError(t, b.store([]byte("first")))
+	require.NoError(t, b.store([]byte("second")))
+
+	entries, err := b.entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	payload, err := b.load(entries[0])
+	require.NoError(t, err)
+	require.Equal(t, []byte("first"), payload)
+
+	b.remove(entries[0])
+	entries, err = b.entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestRetryBufferSizeLimit(t *testing.T) {
+	b := newRetryBuffer(RetryBufferConfig{Directory: t.TempDir(), MaxSizeMiB: 1, MaxAge: time.Hour}, "decouple", zap.NewNop())
+
+	half := bytes.Repeat([]byte("a"), 1<<19)
+	require.NoError(t, b.store(half))
+	require.NoError(t, b.store(half))
+	// Storing a third payload evicts the oldest one.
+	require.NoError(t, b.store(bytes.Repeat([]byte("b"), 1<<19)))
+
+	entries, err := b.entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	payload, err := b.load(entries[1])
+	require.NoError(t, err)
+	require.Equal(t, byte('b'), payload[0])
+
+	require.ErrorIs(t, b.store(bytes.Repeat([]byte("c"), 1<<21)), payloadTooLargeError)
+}
+
+func TestRetryBufferMaxAge(t *testing.T) {
+	b := newRetryBuffer(RetryBufferConfig{Directory: t.TempDir(), MaxSizeMiB: 1, MaxAge: time.Minute}, "decouple", zap.NewNop())
+
+	require.NoError(t, b.store([]byte("old")))
+	require.NoError(t, b.store([]byte("new")))
+	entries, err := b.entries()
+	require.NoError(t, err)
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(entries[0].path, old, old))
+
+	entries, err = b.entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	payload, err := b.load(entries[0])
+	require.NoError(t, err)
+	require.Equal(t, []byte("new"), payload)
+}
@@ -0,0 +1,216 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoupleprocessor // import "github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor"
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const meterScope = "github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor"
+
+type breakerState int64
+
+const (
+	stateClosed breakerState = iota
+	stateHalfOpen
+	stateOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateClosed:
+		return "closed"
+	case stateHalfOpen:
+		return "half-open"
+	case stateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker stops exports once several consecutive invocations failed to export any data, so that the time
+// after each invocation is not spent on timeouts while the backend is unreachable. Once the cool-down has passed,
+// the circuit becomes half-open and the next export is used as a probe: if it succeeds the circuit closes, otherwise
+// it opens again. Other exports are rejected while the probe is in flight.
+type circuitBreaker struct {
+	logger *zap.Logger
+	cfg    CircuitBreakerConfig
+	now    func() time.Time
+
+	skipped metric.Int64Counter
+
+	lock                 sync.Mutex
+	state                breakerState
+	consecutiveFailures  uint32
+	invocationFailed     bool
+	invocationSucceeded  bool
+	openedAt             time.Time
+	invocationsSinceOpen uint32
+	// probing is set while the probe export of a half-open circuit is in flight.
+	probing bool
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig, logger *zap.Logger, meterProvider metric.MeterProvider) (*circuitBreaker, error) {
+	cb := &circuitBreaker{
+		logger: logger,
+		cfg:    cfg,
+		now:    time.Now,
+	}
+
+	meter := meterProvider.Meter(meterScope)
+	var err error
+	cb.skipped, err = meter.Int64Counter(
+		"otelcol_processor_decouple_circuit_breaker_skipped",
+		metric.WithDescription("Number of payloads not exported because the circuit breaker was open."),
+		metric.WithUnit("{payloads}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = meter.Int64ObservableGauge(
+		"otelcol_processor_decouple_circuit_breaker_state",
+		metric.WithDescription("State of the circuit breaker: 0 closed, 1 half-open, 2 open."),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(cb.currentState()))
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cb, nil
+}
+
+func (cb *circuitBreaker) currentState() breakerState {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return cb.state
+}
+
+// allow reports whether data may be exported. A half-open circuit only allows one export, the probe, until its result
+// is recorded or it is abandoned.
+func (cb *circuitBreaker) allow() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	switch cb.state {
+	case stateClosed:
+		return true
+	case stateHalfOpen:
+		if cb.probing {
+			return false
+		}
+		cb.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+// abandon releases an allowed export whose result is not recorded, so that a half-open circuit can probe again.
+func (cb *circuitBreaker) abandon() {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	cb.probing = false
+}
+
+// recordSkipped records that a payload was not exported because the circuit was open.
+func (cb *circuitBreaker) recordSkipped(action string) {
+	cb.skipped.Add(context.Background(), 1, metric.WithAttributes(attribute.String("action", action)))
+}
+
+// recordResult records the outcome of an export.
+func (cb *circuitBreaker) recordResult(failed bool) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	cb.probing = false
+	if !failed {
+		cb.invocationSucceeded = true
+		if cb.state == stateHalfOpen {
+			cb.logger.Info("circuit breaker closed, export succeeded")
+			cb.state = stateClosed
+			cb.consecutiveFailures = 0
+		}
+		return
+	}
+
+	cb.invocationFailed = true
+	if cb.state == stateHalfOpen {
+		cb.open("probe export failed")
+	}
+}
+
+// invocationStarted resets the outcome of the current invocation, and moves an open circuit to half-open once the
+// cool-down has passed.
+func (cb *circuitBreaker) invocationStarted() {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	cb.invocationFailed = false
+	cb.invocationSucceeded = false
+	if cb.state != stateOpen {
+		return
+	}
+
+	cb.invocationsSinceOpen++
+	invocationsPassed := cb.cfg.CooldownInvocations > 0 && cb.invocationsSinceOpen >= cb.cfg.CooldownInvocations
+	durationPassed := cb.cfg.CooldownDuration > 0 && cb.now().Sub(cb.openedAt) >= cb.cfg.CooldownDuration
+	if invocationsPassed || durationPassed {
+		cb.logger.Info("circuit breaker half-open, probing the next export")
+		cb.state = stateHalfOpen
+		cb.probing = false
+	}
+}
+
+// invocationFinished counts the consecutive invocations in which every export failed, and opens the circuit once
+// the failure threshold is reached.
+func (cb *circuitBreaker) invocationFinished() {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if cb.state != stateClosed {
+		return
+	}
+	switch {
+	case cb.invocationSucceeded:
+		cb.consecutiveFailures = 0
+	case cb.invocationFailed:
+		cb.consecutiveFailures++
+		if cb.consecutiveFailures >= cb.cfg.FailureThreshold {
+			cb.open("failure threshold reached")
+		}
+	}
+}
+
+func (cb *circuitBreaker) open(reason string) {
+	cb.logger.Warn("circuit breaker opened, exports are suspended",
+		zap.String("reason", reason),
+		zap.Uint32("consecutive_failed_invocations", cb.consecutiveFailures),
+		zap.Uint32("cooldown_invocations", cb.cfg.CooldownInvocations),
+		zap.Duration("cooldown_duration", cb.cfg.CooldownDuration),
+		zap.String("on_open", cb.cfg.OnOpen),
+	)
+	cb.state = stateOpen
+	cb.openedAt = cb.now()
+	cb.invocationsSinceOpen = 0
+}
@@ -0,0 +1,228 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoupleprocessor // import "github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor"
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+func newTestCircuitBreaker(t *testing.T, cfg CircuitBreakerConfig) *circuitBreaker {
+	cb, err := newCircuitBreaker(cfg, zap.NewNop(), noop.NewMeterProvider())
+	require.NoError(t, err)
+	return cb
+}
+
+func failInvocation(cb *circuitBreaker) {
+	cb.invocationStarted()
+	cb.recordResult(true)
+	cb.invocationFinished()
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	cb := newTestCircuitBreaker(t, CircuitBreakerConfig{FailureThreshold: 2, CooldownInvocations: 2, OnOpen: onOpenDrop})
+
+	failInvocation(cb)
+	require.Equal(t, stateClosed, cb.currentState())
+
+	// A successful export resets the count of consecutive failed invocations.
+	cb.invocationStarted()
+	cb.recordResult(true)
+	cb.recordResult(false)
+	cb.invocationFinished()
+	failInvocation(cb)
+	require.Equal(t, stateClosed, cb.currentState())
+
+	failInvocation(cb)
+	require.Equal(t, stateOpen, cb.currentState())
+	require.False(t, cb.allow())
+}
+
+func TestCircuitBreakerInvocationCooldown(t *testing.T) {
+	cb := newTestCircuitBreaker(t, CircuitBreakerConfig{FailureThreshold: 1, CooldownInvocations: 2, OnOpen: onOpenDrop})
+	failInvocation(cb)
+	require.Equal(t, stateOpen, cb.currentState())
+
+	cb.invocationStarted()
+	cb.invocationFinished()
+	require.Equal(t, stateOpen, cb.currentState())
+
+	// The failed probe opens the circuit again and restarts the cool-down.
+	cb.invocationStarted()
+	require.Equal(t, stateHalfOpen, cb.currentState())
+	require.True(t, cb.allow())
+	cb.recordResult(true)
+	require.Equal(t, stateOpen, cb.currentState())
+	cb.invocationFinished()
+
+	cb.invocationStarted()
+	cb.invocationFinished()
+	cb.invocationStarted()
+	require.Equal(t, stateHalfOpen, cb.currentState())
+	cb.recordResult(false)
+	require.Equal(t, stateClosed, cb.currentState())
+}
+
+func TestCircuitBreakerDurationCooldown(t *testing.T) {
+	now := time.Now()
+	cb := newTestCircuitBreaker(t, CircuitBreakerConfig{FailureThreshold: 1, CooldownDuration: time.Minute, OnOpen: onOpenDrop})
+	cb.now = func() time.Time { return now }
+	failInvocation(cb)
+	require.Equal(t, stateOpen, cb.currentState())
+
+	now = now.Add(30 * time.Second)
+	cb.invocationStarted()
+	require.Equal(t, stateOpen, cb.currentState())
+	cb.invocationFinished()
+
+	now = now.Add(30 * time.Second)
+	cb.invocationStarted()
+	require.Equal(t, stateHalfOpen, cb.currentState())
+}
+
+func TestCircuitBreakerMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	cb, err := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, CooldownInvocations: 1, OnOpen: onOpenDrop}, zap.NewNop(), sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+	failInvocation(cb)
+	cb.recordSkipped(onOpenDrop)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	values := map[string]int64{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Gauge[int64]:
+			values[m.Name] = data.DataPoints[0].Value
+		case metricdata.Sum[int64]:
+			values[m.Name] = data.DataPoints[0].Value
+		}
+	}
+	require.Equal(t, int64(stateOpen), values["otelcol_processor_decouple_circuit_breaker_state"])
+	require.Equal(t, int64(1), values["otelcol_processor_decouple_circuit_breaker_skipped"])
+}
+
+func TestCircuitBreakerDropsWhileOpen(t *testing.T) {
+	lambdalifecycle.SetNotifier(&MockLifecycleNotifier{})
+	next := &flakyTracesConsumer{err: errors.New("backend unavailable")}
+	config := &Config{
+		MaxQueueSize:   10,
+		Workers:        1,
+		CircuitBreaker: CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, CooldownInvocations: 2, OnOpen: onOpenDrop},
+	}
+	dp, err := newDecoupleTracesProcessor(config, next, processortest.NewNopSettings(Type))
+	require.NoError(t, err)
+
+	dp.FunctionInvoked()
+	dp.queueData(context.Background(), newTestTraces("failed"))
+	dp.FunctionFinished()
+	require.Equal(t, stateOpen, dp.breaker.currentState())
+
+	// The backend is reachable again, but data is dropped until the cool-down has passed.
+	next.err = nil
+	dp.FunctionInvoked()
+	dp.queueData(context.Background(), newTestTraces("dropped"))
+	dp.FunctionFinished()
+	require.Equal(t, 0, next.SpanCount())
+
+	dp.FunctionInvoked()
+	dp.queueData(context.Background(), newTestTraces("probe"))
+	dp.FunctionFinished()
+	require.Equal(t, 1, next.SpanCount())
+	require.Equal(t, stateClosed, dp.breaker.currentState())
+
+	require.NoError(t, dp.shutdown(context.Background()))
+}
+
+func TestCircuitBreakerBuffersWhileOpen(t *testing.T) {
+	lambdalifecycle.SetNotifier(&MockLifecycleNotifier{})
+	next := &flakyTracesConsumer{err: errors.New("backend unavailable")}
+	config := &Config{
+		MaxQueueSize:   10,
+		Workers:        1,
+		RetryBuffer:    RetryBufferConfig{Enabled: true, Directory: t.TempDir(), MaxSizeMiB: 1, MaxAge: time.Hour, ReplayTimeout: time.Second, MaxReplayEntries: 10},
+		CircuitBreaker: CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, CooldownInvocations: 2, OnOpen: onOpenBuffer},
+	}
+	dp, err := newDecoupleTracesProcessor(config, next, processortest.NewNopSettings(Type))
+	require.NoError(t, err)
+
+	dp.FunctionInvoked()
+	dp.queueData(context.Background(), newTestTraces("failed"))
+	dp.FunctionFinished()
+	require.Equal(t, stateOpen, dp.breaker.currentState())
+
+	next.err = nil
+	dp.FunctionInvoked()
+	dp.queueData(context.Background(), newTestTraces("buffered"))
+	dp.FunctionFinished()
+	require.Equal(t, 0, next.SpanCount())
+	entries, err := dp.buffer.entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	// Once half-open, replaying the buffer probes the backend and the circuit closes.
+	dp.FunctionInvoked()
+	dp.FunctionFinished()
+	require.Equal(t, 2, next.SpanCount())
+	require.Equal(t, stateClosed, dp.breaker.currentState())
+
+	require.NoError(t, dp.shutdown(context.Background()))
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	cb := newTestCircuitBreaker(t, CircuitBreakerConfig{FailureThreshold: 1, CooldownInvocations: 1, OnOpen: onOpenDrop})
+	failInvocation(cb)
+	cb.invocationStarted()
+	require.Equal(t, stateHalfOpen, cb.currentState())
+
+	// Only one of the concurrent exports is allowed as the probe.
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if cb.allow() {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), allowed.Load())
+	require.False(t, cb.allow(), "rejected until the result of the probe is recorded")
+
+	// An abandoned probe lets another export probe.
+	cb.abandon()
+	require.True(t, cb.allow())
+	require.False(t, cb.allow())
+
+	cb.recordResult(false)
+	require.Equal(t, stateClosed, cb.currentState())
+	require.True(t, cb.allow())
+	require.True(t, cb.allow())
+}
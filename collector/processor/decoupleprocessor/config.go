@@ -13,19 +13,132 @@
 // limitations under the License.
 
 package decoupleprocessor // import "github.com/open-telemetry/opentelemetry-lambda/collector/processor/decoupleprocessor"
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // Config defines the configuration for the various elements of the processor.
 type Config struct {
 	MaxQueueSize uint32 `mapstructure:"max_queue_size"`
+	// Workers is the number of goroutines forwarding queued data to the next consumer.
+	Workers uint32 `mapstructure:"workers"`
+	// Coalesce controls merging of queued data into larger payloads before forwarding.
+	Coalesce CoalesceConfig `mapstructure:"coalesce"`
+	// RetryBuffer controls the persistence of failed exports so that they can be replayed in a later invocation.
+	RetryBuffer RetryBufferConfig `mapstructure:"retry_buffer"`
+	// CircuitBreaker controls the suspension of exports while the backend is unreachable.
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
 }
 
-var invalidMaxQueueSizeError = errors.New("max_queue_size must be greater than 0")
+// CoalesceConfig defines how queued data is merged before being forwarded.
+type CoalesceConfig struct {
+	// Enabled merges queued data with matching client information into a single payload.
+	Enabled bool `mapstructure:"enabled"`
+	// MaxItems is the maximum number of queued entries merged into a single payload.
+	MaxItems uint32 `mapstructure:"max_items"`
+}
+
+// RetryBufferConfig defines how failed exports are persisted and replayed.
+type RetryBufferConfig struct {
+	// Enabled persists data that failed to export and replays it at the next invocation or on shutdown.
+	Enabled bool `mapstructure:"enabled"`
+	// Directory is where failed exports are stored. It should be located in /tmp, the only writable location in Lambda.
+	Directory string `mapstructure:"directory"`
+	// MaxSizeMiB is the maximum size of the persisted data. The oldest data is dropped once the limit is reached.
+	MaxSizeMiB uint32 `mapstructure:"max_size_mib"`
+	// MaxAge is the maximum age of persisted data. Older data is dropped instead of being replayed.
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// ReplayTimeout bounds the time spent replaying persisted data in each invocation. The end of the invocation does
+	// not wait for the replay longer than this.
+	ReplayTimeout time.Duration `mapstructure:"replay_timeout"`
+	// MaxReplayEntries is the maximum number of persisted entries replayed in each invocation.
+	MaxReplayEntries uint32 `mapstructure:"max_replay_entries"`
+}
+
+const (
+	// onOpenDrop drops data while the circuit is open.
+	onOpenDrop = "drop"
+	// onOpenBuffer stores data in the retry buffer while the circuit is open.
+	onOpenBuffer = "buffer"
+)
+
+// CircuitBreakerConfig defines when exports are suspended and resumed.
+type CircuitBreakerConfig struct {
+	// Enabled suspends exports after several consecutive invocations failed to export.
+	Enabled bool `mapstructure:"enabled"`
+	// FailureThreshold is the number of consecutive invocations in which every export failed before the circuit opens.
+	FailureThreshold uint32 `mapstructure:"failure_threshold"`
+	// CooldownInvocations is the number of invocations after which an open circuit probes the backend again.
+	// Zero disables the invocation based cool-down.
+	CooldownInvocations uint32 `mapstructure:"cooldown_invocations"`
+	// CooldownDuration is the time after which an open circuit probes the backend again.
+	// Zero disables the time based cool-down.
+	CooldownDuration time.Duration `mapstructure:"cooldown_duration"`
+	// OnOpen selects what happens to data while the circuit is open, either "drop" or "buffer". Buffering requires
+	// the retry buffer to be enabled.
+	OnOpen string `mapstructure:"on_open"`
+}
+
+var (
+	invalidMaxQueueSizeError      = errors.New("max_queue_size must be greater than 0")
+	invalidWorkersError           = errors.New("workers must be greater than 0")
+	invalidCoalesceMaxItemsError  = errors.New("coalesce::max_items must be greater than 0 when coalescing is enabled")
+	invalidRetryBufferDirError    = errors.New("retry_buffer::directory must be set when the retry buffer is enabled")
+	invalidRetryBufferSizeError   = errors.New("retry_buffer::max_size_mib must be greater than 0 when the retry buffer is enabled")
+	invalidRetryBufferAgeError    = errors.New("retry_buffer::max_age must be greater than 0 when the retry buffer is enabled")
+	invalidReplayTimeoutError     = errors.New("retry_buffer::replay_timeout must be greater than 0 when the retry buffer is enabled")
+	invalidMaxReplayEntriesError  = errors.New("retry_buffer::max_replay_entries must be greater than 0 when the retry buffer is enabled")
+	invalidFailureThresholdError  = errors.New("circuit_breaker::failure_threshold must be greater than 0 when the circuit breaker is enabled")
+	invalidCooldownError          = errors.New("circuit_breaker::cooldown_invocations or circuit_breaker::cooldown_duration must be greater than 0 when the circuit breaker is enabled")
+	invalidOnOpenError            = errors.New("circuit_breaker::on_open must be either \"drop\" or \"buffer\"")
+	bufferWithoutRetryBufferError = errors.New("circuit_breaker::on_open \"buffer\" requires the retry buffer to be enabled")
+)
 
 // Validate validates the configuration by checking for missing or invalid fields
 func (cfg *Config) Validate() error {
 	if cfg.MaxQueueSize == 0 {
 		return invalidMaxQueueSizeError
 	}
+	if cfg.Workers == 0 {
+		return invalidWorkersError
+	}
+	if cfg.Coalesce.Enabled && cfg.Coalesce.MaxItems == 0 {
+		return invalidCoalesceMaxItemsError
+	}
+	if cfg.RetryBuffer.Enabled {
+		if cfg.RetryBuffer.Directory == "" {
+			return invalidRetryBufferDirError
+		}
+		if cfg.RetryBuffer.MaxSizeMiB == 0 {
+			return invalidRetryBufferSizeError
+		}
+		if cfg.RetryBuffer.MaxAge <= 0 {
+			return invalidRetryBufferAgeError
+		}
+		if cfg.RetryBuffer.ReplayTimeout <= 0 {
+			return invalidReplayTimeoutError
+		}
+		if cfg.RetryBuffer.MaxReplayEntries == 0 {
+			return invalidMaxReplayEntriesError
+		}
+	}
+	if cfg.CircuitBreaker.Enabled {
+		if cfg.CircuitBreaker.FailureThreshold == 0 {
+			return invalidFailureThresholdError
+		}
+		if cfg.CircuitBreaker.CooldownInvocations == 0 && cfg.CircuitBreaker.CooldownDuration <= 0 {
+			return invalidCooldownError
+		}
+		switch cfg.CircuitBreaker.OnOpen {
+		case onOpenDrop:
+		case onOpenBuffer:
+			if !cfg.RetryBuffer.Enabled {
+				return bufferWithoutRetryBufferError
+			}
+		default:
+			return invalidOnOpenError
+		}
+	}
 	return nil
 }
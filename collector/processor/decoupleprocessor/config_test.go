@@ -17,6 +17,7 @@ package decoupleprocessor // import "github.com/open-telemetry/opentelemetry-lam
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/confmap/confmaptest"
@@ -36,6 +37,7 @@ func TestValidate(t *testing.T) {
 			desc: "valid config",
 			cfg: &Config{
 				MaxQueueSize: 1,
+				Workers:      1,
 			},
 			expectedErr: nil,
 		},
@@ -44,6 +46,103 @@ func TestValidate(t *testing.T) {
 			cfg:         &Config{},
 			expectedErr: invalidMaxQueueSizeError,
 		},
+		{
+			desc: "invalid workers",
+			cfg: &Config{
+				MaxQueueSize: 1,
+			},
+			expectedErr: invalidWorkersError,
+		},
+		{
+			desc: "invalid coalesce max items",
+			cfg: &Config{
+				MaxQueueSize: 1,
+				Workers:      1,
+				Coalesce:     CoalesceConfig{Enabled: true},
+			},
+			expectedErr: invalidCoalesceMaxItemsError,
+		},
+		{
+			desc: "invalid retry buffer directory",
+			cfg: &Config{
+				MaxQueueSize: 1,
+				Workers:      1,
+				RetryBuffer:  RetryBufferConfig{Enabled: true, MaxSizeMiB: 1, MaxAge: time.Minute},
+			},
+			expectedErr: invalidRetryBufferDirError,
+		},
+		{
+			desc: "invalid retry buffer size",
+			cfg: &Config{
+				MaxQueueSize: 1,
+				Workers:      1,
+				RetryBuffer:  RetryBufferConfig{Enabled: true, Directory: "/tmp", MaxAge: time.Minute},
+			},
+			expectedErr: invalidRetryBufferSizeError,
+		},
+		{
+			desc: "invalid retry buffer age",
+			cfg: &Config{
+				MaxQueueSize: 1,
+				Workers:      1,
+				RetryBuffer:  RetryBufferConfig{Enabled: true, Directory: "/tmp", MaxSizeMiB: 1},
+			},
+			expectedErr: invalidRetryBufferAgeError,
+		},
+		{
+			desc: "invalid retry buffer replay timeout",
+			cfg: &Config{
+				MaxQueueSize: 1,
+				Workers:      1,
+				RetryBuffer:  RetryBufferConfig{Enabled: true, Directory: "/tmp", MaxSizeMiB: 1, MaxAge: time.Minute, MaxReplayEntries: 1},
+			},
+			expectedErr: invalidReplayTimeoutError,
+		},
+		{
+			desc: "invalid retry buffer max replay entries",
+			cfg: &Config{
+				MaxQueueSize: 1,
+				Workers:      1,
+				RetryBuffer:  RetryBufferConfig{Enabled: true, Directory: "/tmp", MaxSizeMiB: 1, MaxAge: time.Minute, ReplayTimeout: time.Second},
+			},
+			expectedErr: invalidMaxReplayEntriesError,
+		},
+		{
+			desc: "invalid circuit breaker failure threshold",
+			cfg: &Config{
+				MaxQueueSize:   1,
+				Workers:        1,
+				CircuitBreaker: CircuitBreakerConfig{Enabled: true, CooldownInvocations: 1, OnOpen: onOpenDrop},
+			},
+			expectedErr: invalidFailureThresholdError,
+		},
+		{
+			desc: "invalid circuit breaker cooldown",
+			cfg: &Config{
+				MaxQueueSize:   1,
+				Workers:        1,
+				CircuitBreaker: CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, OnOpen: onOpenDrop},
+			},
+			expectedErr: invalidCooldownError,
+		},
+		{
+			desc: "invalid circuit breaker on_open",
+			cfg: &Config{
+				MaxQueueSize:   1,
+				Workers:        1,
+				CircuitBreaker: CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, CooldownInvocations: 1, OnOpen: "retry"},
+			},
+			expectedErr: invalidOnOpenError,
+		},
+		{
+			desc: "circuit breaker buffering without retry buffer",
+			cfg: &Config{
+				MaxQueueSize:   1,
+				Workers:        1,
+				CircuitBreaker: CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, CooldownInvocations: 1, OnOpen: onOpenBuffer},
+			},
+			expectedErr: bufferWithoutRetryBufferError,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -59,6 +158,14 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+var defaultCircuitBreaker = CircuitBreakerConfig{
+	Enabled:             false,
+	FailureThreshold:    3,
+	CooldownInvocations: 10,
+	CooldownDuration:    time.Minute,
+	OnOpen:              onOpenDrop,
+}
+
 func TestLoadConfig(t *testing.T) {
 	t.Parallel()
 
@@ -70,6 +177,66 @@ func TestLoadConfig(t *testing.T) {
 			id: component.NewIDWithName(component.MustNewType(typeStr), ""),
 			expected: &Config{
 				MaxQueueSize: 100,
+				Workers:      1,
+				Coalesce: CoalesceConfig{
+					Enabled:  false,
+					MaxItems: 50,
+				},
+				RetryBuffer: RetryBufferConfig{
+					Enabled:          false,
+					Directory:        "/tmp/otel-lambda-retry",
+					MaxSizeMiB:       64,
+					MaxAge:           time.Hour,
+					ReplayTimeout:    time.Second,
+					MaxReplayEntries: 10,
+				},
+				CircuitBreaker: defaultCircuitBreaker,
+			},
+		},
+		{
+			id: component.NewIDWithName(component.MustNewType(typeStr), "coalesce"),
+			expected: &Config{
+				MaxQueueSize: 200,
+				Workers:      4,
+				Coalesce: CoalesceConfig{
+					Enabled:  true,
+					MaxItems: 20,
+				},
+				RetryBuffer: RetryBufferConfig{
+					Enabled:          false,
+					Directory:        "/tmp/otel-lambda-retry",
+					MaxSizeMiB:       64,
+					MaxAge:           time.Hour,
+					ReplayTimeout:    time.Second,
+					MaxReplayEntries: 10,
+				},
+				CircuitBreaker: defaultCircuitBreaker,
+			},
+		},
+		{
+			id: component.NewIDWithName(component.MustNewType(typeStr), "retry"),
+			expected: &Config{
+				MaxQueueSize: 200,
+				Workers:      1,
+				Coalesce: CoalesceConfig{
+					Enabled:  false,
+					MaxItems: 50,
+				},
+				RetryBuffer: RetryBufferConfig{
+					Enabled:          true,
+					Directory:        "/tmp/retry",
+					MaxSizeMiB:       8,
+					MaxAge:           10 * time.Minute,
+					ReplayTimeout:    500 * time.Millisecond,
+					MaxReplayEntries: 5,
+				},
+				CircuitBreaker: CircuitBreakerConfig{
+					Enabled:             true,
+					FailureThreshold:    5,
+					CooldownInvocations: 0,
+					CooldownDuration:    30 * time.Second,
+					OnOpen:              onOpenBuffer,
+				},
 			},
 		},
 		{
@@ -17,6 +17,7 @@ package decoupleprocessor // import "github.com/open-telemetry/opentelemetry-lam
 import (
 	"context"
 	"errors"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
@@ -30,11 +31,16 @@ const (
 )
 
 var (
-	Type                  = component.MustNewType(typeStr)
-	errConfigNotDecouple  = errors.New("config was not a decouple processor config")
-	processorCapabilities = consumer.Capabilities{MutatesData: false}
+	Type                 = component.MustNewType(typeStr)
+	errConfigNotDecouple = errors.New("config was not a decouple processor config")
 )
 
+// processorCapabilities reports that data is mutated when coalescing is enabled, as queued payloads are merged
+// into each other before being forwarded.
+func processorCapabilities(cfg *Config) consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: cfg.Coalesce.Enabled}
+}
+
 func NewFactory() processor.Factory {
 	return processor.NewFactory(
 		Type,
@@ -48,6 +54,26 @@ func NewFactory() processor.Factory {
 func createDefaultConfig() component.Config {
 	return &Config{
 		MaxQueueSize: 200,
+		Workers:      1,
+		Coalesce: CoalesceConfig{
+			Enabled:  false,
+			MaxItems: 50,
+		},
+		RetryBuffer: RetryBufferConfig{
+			Enabled:          false,
+			Directory:        "/tmp/otel-lambda-retry",
+			MaxSizeMiB:       64,
+			MaxAge:           time.Hour,
+			ReplayTimeout:    time.Second,
+			MaxReplayEntries: 10,
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Enabled:             false,
+			FailureThreshold:    3,
+			CooldownInvocations: 10,
+			CooldownDuration:    time.Minute,
+			OnOpen:              onOpenDrop,
+		},
 	}
 }
 
@@ -67,7 +93,8 @@ func createTracesProcessor(ctx context.Context, params processor.Settings, rConf
 		cfg,
 		next,
 		dp.processTraces,
-		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithCapabilities(processorCapabilities(cfg)),
+		processorhelper.WithStart(dp.start),
 		processorhelper.WithShutdown(dp.shutdown),
 	)
 }
@@ -88,7 +115,8 @@ func createMetricsProcessor(ctx context.Context, params processor.Settings, rCon
 		cfg,
 		next,
 		dp.processMetrics,
-		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithCapabilities(processorCapabilities(cfg)),
+		processorhelper.WithStart(dp.start),
 		processorhelper.WithShutdown(dp.shutdown),
 	)
 }
@@ -109,7 +137,8 @@ func createLogsProcessor(ctx context.Context, params processor.Settings, rConf c
 		cfg,
 		next,
 		dp.processLogs,
-		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithCapabilities(processorCapabilities(cfg)),
+		processorhelper.WithStart(dp.start),
 		processorhelper.WithShutdown(dp.shutdown),
 	)
 }
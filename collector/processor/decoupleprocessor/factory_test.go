@@ -21,7 +21,10 @@ import (
 	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/processor"
 	"go.opentelemetry.io/collector/processor/processortest"
 )
 
@@ -71,3 +74,42 @@ func TestNewFactory(t *testing.T) {
 		t.Run(tc.desc, tc.testFunc)
 	}
 }
+
+// TestRetryBufferPipelines creates the processors of two pipelines of the same signal, as the collector does when
+// both pipelines list the same decouple processor.
+func TestRetryBufferPipelines(t *testing.T) {
+	lambdalifecycle.SetNotifier(&MockLifecycleNotifier{})
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.RetryBuffer.Enabled = true
+	cfg.RetryBuffer.Directory = t.TempDir()
+	ctx := context.Background()
+	create := func(set processor.Settings) processor.Traces {
+		p, err := factory.CreateTraces(ctx, set, cfg, consumertest.NewNop())
+		require.NoError(t, err)
+		return p
+	}
+
+	first := create(processortest.NewNopSettings(Type))
+	second := create(processortest.NewNopSettings(Type))
+	require.NoError(t, first.Start(ctx, componenttest.NewNopHost()))
+	require.ErrorContains(t, second.Start(ctx, componenttest.NewNopHost()), "already used by another pipeline")
+	require.NoError(t, second.Shutdown(ctx))
+
+	// The processors of other signals, or with a distinct name, use their own directory.
+	logs, err := factory.CreateLogs(ctx, processortest.NewNopSettings(Type), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, logs.Start(ctx, componenttest.NewNopHost()))
+	named := processortest.NewNopSettings(Type)
+	named.ID = component.NewIDWithName(Type, "b")
+	other := create(named)
+	require.NoError(t, other.Start(ctx, componenttest.NewNopHost()))
+
+	// The directory is released on shutdown, as when the collector is restarted.
+	require.NoError(t, first.Shutdown(ctx))
+	second = create(processortest.NewNopSettings(Type))
+	require.NoError(t, second.Start(ctx, componenttest.NewNopHost()))
+	for _, p := range []component.Component{second, logs, other} {
+		require.NoError(t, p.Shutdown(ctx))
+	}
+}
@@ -17,10 +17,14 @@ package decoupleprocessor // import "github.com/open-telemetry/opentelemetry-lam
 import (
 	"context"
 	"errors"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
 	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/processor/processorhelper"
@@ -38,6 +42,13 @@ var (
 
 type decoupleConsumer interface {
 	consume(context.Context, any) error
+	// merge appends the contents of src to dst. It returns false if the data could not be merged.
+	merge(dst any, src any) bool
+	// marshal and unmarshal convert data to and from the representation stored in the retry buffer.
+	marshal(data any) ([]byte, error)
+	unmarshal(payload []byte) (any, error)
+	// signal is the name of the signal handled by the consumer.
+	signal() string
 }
 
 type contextualData struct {
@@ -49,9 +60,24 @@ type decoupleProcessor struct {
 	logger   *zap.Logger
 	consumer decoupleConsumer
 
-	data chan contextualData
+	workers          int
+	coalesce         bool
+	maxCoalesceItems int
 
-	wg sync.WaitGroup
+	data    chan contextualData
+	buffer  *retryBuffer
+	breaker *circuitBreaker
+	onOpen  string
+
+	replayTimeout    time.Duration
+	maxReplayEntries int
+
+	lock    sync.Mutex
+	running bool
+	wg      sync.WaitGroup
+	// replayCtx bounds the replay of the retry buffer in progress, if any, and replayDone is closed once it returns.
+	replayCtx  context.Context
+	replayDone chan struct{}
 }
 
 func (p *decoupleProcessor) queueData(ctx context.Context, data any) {
@@ -77,40 +103,264 @@ func (p *decoupleProcessor) processLogs(ctx context.Context, ld plog.Logs) (plog
 }
 
 func (p *decoupleProcessor) startForwardingData() {
-	p.wg.Add(1)
-	go func() {
-		defer p.wg.Done()
-		p.logger.Info("started forwarding data")
-		for {
-			d := <-p.data
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.running {
+		return
+	}
+	p.running = true
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.forwardData()
+	}
+	if p.buffer != nil && !p.replaying() {
+		ctx, cancel := context.WithTimeout(context.Background(), p.replayTimeout)
+		done := make(chan struct{})
+		p.replayCtx, p.replayDone = ctx, done
+		go func() {
+			defer close(done)
+			defer cancel()
+			p.replayBuffered(ctx)
+		}()
+	}
+	p.logger.Info("started forwarding data", zap.Int("workers", p.workers))
+}
+
+// replaying reports whether a replay of the retry buffer is still running. It must be called with the lock held.
+func (p *decoupleProcessor) replaying() bool {
+	if p.replayDone == nil {
+		return false
+	}
+	select {
+	case <-p.replayDone:
+		return false
+	default:
+		return true
+	}
+}
+
+func (p *decoupleProcessor) forwardData() {
+	defer p.wg.Done()
+	for {
+		d := <-p.data
+		if d.data == nil {
+			return
+		}
+		if !p.coalesce {
+			p.forward(d)
+			continue
+		}
+		pending, stop := p.coalesceQueued(d)
+		for _, c := range pending {
+			p.forward(c)
+		}
+		if stop {
+			return
+		}
+	}
+}
+
+// coalesceQueued merges the data already waiting in the queue into as few payloads as possible, without waiting for
+// new data to arrive. Only data with matching client information is merged. The returned flag reports whether a
+// stop marker was taken from the queue, in which case the worker must exit once the payloads have been forwarded.
+func (p *decoupleProcessor) coalesceQueued(first contextualData) ([]contextualData, bool) {
+	pending := []contextualData{first}
+	for n := 1; n < p.maxCoalesceItems; n++ {
+		select {
+		case d := <-p.data:
 			if d.data == nil {
-				break
+				return pending, true
 			}
-			if err := p.consumer.consume(client.NewContext(context.Background(), d.info), d.data); err != nil {
-				p.logger.Error("next consumer failed", zap.Error(err))
+			pending = p.mergeOrAppend(pending, d)
+		default:
+			return pending, false
+		}
+	}
+	return pending, false
+}
+
+func (p *decoupleProcessor) mergeOrAppend(pending []contextualData, d contextualData) []contextualData {
+	for _, c := range pending {
+		if reflect.DeepEqual(c.info, d.info) && p.consumer.merge(c.data, d.data) {
+			return pending
+		}
+	}
+	return append(pending, d)
+}
+
+func (p *decoupleProcessor) forward(d contextualData) {
+	if p.breaker != nil && !p.breaker.allow() {
+		p.skip(d.data)
+		return
+	}
+	err := p.consumer.consume(client.NewContext(context.Background(), d.info), d.data)
+	p.recordResult(err)
+	if err != nil {
+		p.logger.Error("next consumer failed", zap.Error(err))
+		p.storeFailed(d.data, err)
+	}
+}
+
+// recordResult reports the outcome of an export to the circuit breaker. A permanent error means that the backend
+// was reachable and rejected the data, so it is not counted as a failure.
+func (p *decoupleProcessor) recordResult(err error) {
+	if p.breaker != nil {
+		p.breaker.recordResult(err != nil && !consumererror.IsPermanent(err))
+	}
+}
+
+// skip handles data that is not exported because the circuit breaker is open.
+func (p *decoupleProcessor) skip(data any) {
+	p.breaker.recordSkipped(p.onOpen)
+	if p.onOpen == onOpenBuffer {
+		p.storeFailed(data, nil)
+		return
+	}
+	p.logger.Debug("circuit breaker is open, dropping data")
+}
+
+// storeFailed persists data that failed to export in the retry buffer. Data rejected with a permanent error is not
+// stored, as replaying it would fail again.
+func (p *decoupleProcessor) storeFailed(data any, exportErr error) {
+	if p.buffer == nil || consumererror.IsPermanent(exportErr) {
+		return
+	}
+	payload, err := p.consumer.marshal(data)
+	if err != nil {
+		p.logger.Warn("failed to marshal data for the retry buffer", zap.Error(err))
+		return
+	}
+	if err := p.buffer.store(payload); err != nil {
+		p.logger.Warn("failed to store data in the retry buffer", zap.Error(err))
+		return
+	}
+	p.logger.Debug("stored failed export in the retry buffer", zap.Int("bytes", len(payload)))
+}
+
+// replayBuffered forwards the data persisted in the retry buffer, oldest first. Replaying stops at the first
+// failure, as the remaining entries are likely to fail for the same reason; they are kept for the next attempt.
+// It also stops once ctx is done or the maximum number of entries of an invocation is reached, the remaining entries
+// are replayed in the next invocations.
+// The client information of the original request is not persisted, so replayed data is forwarded without it.
+func (p *decoupleProcessor) replayBuffered(ctx context.Context) {
+	entries, err := p.buffer.entries()
+	if err != nil {
+		p.logger.Warn("failed to read the retry buffer", zap.Error(err))
+		return
+	}
+
+	replayed := 0
+	for i, e := range entries {
+		if i == p.maxReplayEntries || ctx.Err() != nil {
+			p.logger.Debug("replay budget of the invocation exhausted, keeping remaining entries", zap.Int("remaining", len(entries)-i))
+			break
+		}
+		payload, err := p.buffer.load(e)
+		if err != nil {
+			p.logger.Warn("dropping unreadable entry from the retry buffer", zap.String("path", e.path), zap.Error(err))
+			p.buffer.remove(e)
+			continue
+		}
+		data, err := p.consumer.unmarshal(payload)
+		if err != nil {
+			p.logger.Warn("dropping corrupt entry from the retry buffer", zap.String("path", e.path), zap.Error(err))
+			p.buffer.remove(e)
+			continue
+		}
+		// The breaker is only asked once the entry is about to be exported, as a half-open circuit allows one probe.
+		if p.breaker != nil && !p.breaker.allow() {
+			p.logger.Debug("circuit breaker is open, not replaying the retry buffer")
+			break
+		}
+		err = p.consumer.consume(ctx, data)
+		if err != nil && ctx.Err() != nil {
+			// The replay ran out of time, which says nothing about the backend.
+			if p.breaker != nil {
+				p.breaker.abandon()
+			}
+			p.logger.Debug("replay budget of the invocation exhausted, keeping remaining entries", zap.Int("remaining", len(entries)-i))
+			break
+		}
+		p.recordResult(err)
+		if err != nil {
+			if consumererror.IsPermanent(err) {
+				p.logger.Warn("dropping rejected entry from the retry buffer", zap.String("path", e.path), zap.Error(err))
+				p.buffer.remove(e)
+				continue
 			}
+			p.logger.Warn("failed to replay the retry buffer, keeping remaining entries", zap.Int("remaining", len(entries)-replayed), zap.Error(err))
+			break
 		}
-		p.logger.Info("stopped forwarding data")
-	}()
+		p.buffer.remove(e)
+		replayed++
+	}
+	if replayed > 0 {
+		p.logger.Info("replayed data from the retry buffer", zap.Int("entries", replayed))
+	}
 }
 
 func (p *decoupleProcessor) stopForwardingData() {
-	p.data <- contextualData{}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.running {
+		return
+	}
+	// Each worker exits on the first stop marker it receives. As the queue is FIFO, all data queued before the
+	// markers has been forwarded once the workers are done.
+	for i := 0; i < p.workers; i++ {
+		p.data <- contextualData{}
+	}
 	p.wg.Wait()
+	// The replay of the retry buffer is only waited for until its deadline, so that a slow backend does not extend
+	// the invocation further. A replay still running then ends in a later invocation, no other one is started
+	// meanwhile.
+	if p.replayDone != nil {
+		select {
+		case <-p.replayDone:
+		case <-p.replayCtx.Done():
+		}
+	}
+	p.running = false
+	p.logger.Info("stopped forwarding data")
+}
+
+func (p *decoupleProcessor) start(context.Context, component.Host) error {
+	if p.buffer != nil {
+		return p.buffer.claim()
+	}
+	return nil
 }
 
 func (p *decoupleProcessor) shutdown(ctx context.Context) error {
 	p.stopForwardingData()
+	if p.buffer != nil {
+		p.buffer.release()
+	}
 	return nil
 }
 
 func (p *decoupleProcessor) FunctionInvoked() {
+	if p.breaker != nil {
+		p.breaker.invocationStarted()
+	}
 	p.startForwardingData()
 }
 
 func (p *decoupleProcessor) FunctionFinished() {
 	// Stop forwarding data to ensure that we don't have issues with network interruptions if the environment is frozen.
 	p.stopForwardingData()
+	if p.breaker != nil {
+		p.breaker.invocationFinished()
+	}
+}
+
+// Pending returns the number of payloads waiting to be forwarded, including those persisted in the retry buffer.
+func (p *decoupleProcessor) Pending() int {
+	pending := len(p.data)
+	if p.buffer != nil {
+		pending += p.buffer.count()
+	}
+	return pending
 }
 
 func (p *decoupleProcessor) EnvironmentShutdown() {
@@ -124,9 +374,25 @@ func newDecoupleProcessor(
 	set processor.Settings,
 ) (*decoupleProcessor, error) {
 	dp := &decoupleProcessor{
-		consumer: consumer,
-		logger:   set.Logger,
-		data:     make(chan contextualData, cfg.MaxQueueSize),
+		consumer:         consumer,
+		logger:           set.Logger,
+		workers:          max(int(cfg.Workers), 1),
+		coalesce:         cfg.Coalesce.Enabled,
+		maxCoalesceItems: int(cfg.Coalesce.MaxItems),
+		data:             make(chan contextualData, cfg.MaxQueueSize),
+		replayTimeout:    cfg.RetryBuffer.ReplayTimeout,
+		maxReplayEntries: int(cfg.RetryBuffer.MaxReplayEntries),
+	}
+	if cfg.RetryBuffer.Enabled {
+		dp.buffer = newRetryBuffer(cfg.RetryBuffer, set.ID.String()+"_"+consumer.signal(), set.Logger)
+	}
+	if cfg.CircuitBreaker.Enabled {
+		breaker, err := newCircuitBreaker(cfg.CircuitBreaker, set.Logger, set.TelemetrySettings.MeterProvider)
+		if err != nil {
+			return nil, err
+		}
+		dp.breaker = breaker
+		dp.onOpen = cfg.CircuitBreaker.OnOpen
 	}
 	if notifier := lambdalifecycle.GetNotifier(); notifier == nil {
 		return nil, noLifecycleNotifierError
@@ -148,6 +414,39 @@ func (tc *decoupleTraceConsumer) consume(ctx context.Context, data any) error {
 	}
 }
 
+func (tc *decoupleTraceConsumer) merge(dst any, src any) bool {
+	to, ok := dst.(*ptrace.Traces)
+	if !ok {
+		return false
+	}
+	from, ok := src.(*ptrace.Traces)
+	if !ok {
+		return false
+	}
+	from.ResourceSpans().MoveAndAppendTo(to.ResourceSpans())
+	return true
+}
+
+func (tc *decoupleTraceConsumer) marshal(data any) ([]byte, error) {
+	if td, ok := data.(*ptrace.Traces); ok {
+		return (&ptrace.ProtoMarshaler{}).MarshalTraces(*td)
+	} else {
+		return nil, incorrectDataTypeError
+	}
+}
+
+func (tc *decoupleTraceConsumer) unmarshal(payload []byte) (any, error) {
+	td, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &td, nil
+}
+
+func (tc *decoupleTraceConsumer) signal() string {
+	return "traces"
+}
+
 func newDecoupleTracesProcessor(cfg *Config,
 	next consumer.Traces,
 	set processor.Settings,
@@ -167,6 +466,39 @@ func (tc *decoupleMetricsConsumer) consume(ctx context.Context, data any) error
 	}
 }
 
+func (tc *decoupleMetricsConsumer) merge(dst any, src any) bool {
+	to, ok := dst.(*pmetric.Metrics)
+	if !ok {
+		return false
+	}
+	from, ok := src.(*pmetric.Metrics)
+	if !ok {
+		return false
+	}
+	from.ResourceMetrics().MoveAndAppendTo(to.ResourceMetrics())
+	return true
+}
+
+func (tc *decoupleMetricsConsumer) marshal(data any) ([]byte, error) {
+	if md, ok := data.(*pmetric.Metrics); ok {
+		return (&pmetric.ProtoMarshaler{}).MarshalMetrics(*md)
+	} else {
+		return nil, incorrectDataTypeError
+	}
+}
+
+func (tc *decoupleMetricsConsumer) unmarshal(payload []byte) (any, error) {
+	md, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
+
+func (tc *decoupleMetricsConsumer) signal() string {
+	return "metrics"
+}
+
 func newDecoupleMetricsProcessor(cfg *Config,
 	next consumer.Metrics,
 	set processor.Settings,
@@ -186,6 +518,39 @@ func (tc *decoupleLogsConsumer) consume(ctx context.Context, data any) error {
 	}
 }
 
+func (tc *decoupleLogsConsumer) merge(dst any, src any) bool {
+	to, ok := dst.(*plog.Logs)
+	if !ok {
+		return false
+	}
+	from, ok := src.(*plog.Logs)
+	if !ok {
+		return false
+	}
+	from.ResourceLogs().MoveAndAppendTo(to.ResourceLogs())
+	return true
+}
+
+func (tc *decoupleLogsConsumer) marshal(data any) ([]byte, error) {
+	if ld, ok := data.(*plog.Logs); ok {
+		return (&plog.ProtoMarshaler{}).MarshalLogs(*ld)
+	} else {
+		return nil, incorrectDataTypeError
+	}
+}
+
+func (tc *decoupleLogsConsumer) unmarshal(payload []byte) (any, error) {
+	ld, err := (&plog.ProtoUnmarshaler{}).UnmarshalLogs(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &ld, nil
+}
+
+func (tc *decoupleLogsConsumer) signal() string {
+	return "logs"
+}
+
 func newDecoupleLogsProcessor(cfg *Config,
 	next consumer.Logs,
 	set processor.Settings,
@@ -10,6 +10,9 @@ import (
 	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/processor/processortest"
 )
 
@@ -35,6 +38,22 @@ func (m *MockConsumer) consume(ctx context.Context, data any) error {
 	return nil
 }
 
+func (m *MockConsumer) merge(dst any, src any) bool {
+	return false
+}
+
+func (m *MockConsumer) marshal(data any) ([]byte, error) {
+	return []byte(data.(string)), nil
+}
+
+func (m *MockConsumer) unmarshal(payload []byte) (any, error) {
+	return string(payload), nil
+}
+
+func (m *MockConsumer) signal() string {
+	return "test"
+}
+
 func (m *MockConsumer) receiveDataAfter(d time.Duration) {
 	go func() {
 		time.Sleep(d)
@@ -164,6 +183,15 @@ func TestLifecycle(t *testing.T) {
 		require.NoError(t, dp.shutdown(context.Background()))
 	})
 
+	t.Run("pending data", func(t *testing.T) {
+		dp, err := newDecoupleProcessor(config, consumer, processortest.NewNopSettings(Type))
+		require.NoError(t, err)
+
+		require.Equal(t, 0, dp.Pending())
+		dp.queueData(client.NewContext(context.Background(), client.Info{}), "data")
+		require.Equal(t, 1, dp.Pending())
+	})
+
 	t.Run("full lifecycle with data before shutdown", func(t *testing.T) {
 		dp, err := newDecoupleProcessor(config, consumer, processortest.NewNopSettings(Type))
 		require.NoError(t, err)
@@ -185,3 +213,212 @@ func TestLifecycle(t *testing.T) {
 		require.Equal(t, expectedData, data)
 	})
 }
+
+type blockingConsumer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingConsumer) consume(ctx context.Context, data any) error {
+	b.started <- struct{}{}
+	<-b.release
+	return nil
+}
+
+func (b *blockingConsumer) merge(dst any, src any) bool {
+	return false
+}
+
+func (b *blockingConsumer) marshal(data any) ([]byte, error) {
+	return []byte(data.(string)), nil
+}
+
+func (b *blockingConsumer) unmarshal(payload []byte) (any, error) {
+	return string(payload), nil
+}
+
+func (b *blockingConsumer) signal() string {
+	return "test"
+}
+
+func TestWorkers(t *testing.T) {
+	lambdalifecycle.SetNotifier(&MockLifecycleNotifier{})
+	consumer := &blockingConsumer{started: make(chan struct{}), release: make(chan struct{})}
+	config := &Config{MaxQueueSize: 10, Workers: 2}
+
+	dp, err := newDecoupleProcessor(config, consumer, processortest.NewNopSettings(Type))
+	require.NoError(t, err)
+
+	dp.FunctionInvoked()
+	dp.queueData(context.Background(), "first")
+	dp.queueData(context.Background(), "second")
+
+	// Both items must be in flight at the same time before either is released.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-consumer.started:
+		case <-time.After(time.Second):
+			t.Fatal("data was not forwarded concurrently")
+		}
+	}
+	close(consumer.release)
+
+	dp.FunctionFinished()
+	require.NoError(t, dp.shutdown(context.Background()))
+}
+
+func newTestTraces(name string) *ptrace.Traces {
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName(name)
+	return &td
+}
+
+func TestCoalesce(t *testing.T) {
+	lambdalifecycle.SetNotifier(&MockLifecycleNotifier{})
+	sink := new(consumertest.TracesSink)
+	config := &Config{MaxQueueSize: 10, Workers: 1, Coalesce: CoalesceConfig{Enabled: true, MaxItems: 3}}
+
+	dp, err := newDecoupleTracesProcessor(config, sink, processortest.NewNopSettings(Type))
+	require.NoError(t, err)
+
+	other := client.NewContext(context.Background(), client.Info{Metadata: client.NewMetadata(map[string][]string{"tenant": {"b"}})})
+
+	// Queue data before forwarding starts so that it is all waiting when the worker begins coalescing.
+	dp.queueData(context.Background(), newTestTraces("a1"))
+	dp.queueData(other, newTestTraces("b1"))
+	dp.queueData(context.Background(), newTestTraces("a2"))
+	dp.queueData(context.Background(), newTestTraces("a3"))
+
+	dp.FunctionInvoked()
+	dp.FunctionFinished()
+
+	// max_items limits the first pass to a1, b1 and a2; a3 is forwarded on its own.
+	require.Len(t, sink.AllTraces(), 3)
+	require.Equal(t, 2, sink.AllTraces()[0].ResourceSpans().Len())
+	require.Equal(t, 1, sink.AllTraces()[1].ResourceSpans().Len())
+	require.Equal(t, "b1", sink.AllTraces()[1].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
+	require.Equal(t, 1, sink.AllTraces()[2].ResourceSpans().Len())
+	require.Equal(t, 4, sink.SpanCount())
+
+	require.NoError(t, dp.shutdown(context.Background()))
+}
+
+type flakyTracesConsumer struct {
+	consumertest.TracesSink
+	err error
+}
+
+func (f *flakyTracesConsumer) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	if f.err != nil {
+		return f.err
+	}
+	return f.TracesSink.ConsumeTraces(ctx, td)
+}
+
+func TestRetryBuffer(t *testing.T) {
+	lambdalifecycle.SetNotifier(&MockLifecycleNotifier{})
+	next := &flakyTracesConsumer{}
+	config := &Config{
+		MaxQueueSize: 10,
+		Workers:      1,
+		RetryBuffer:  RetryBufferConfig{Enabled: true, Directory: t.TempDir(), MaxSizeMiB: 1, MaxAge: time.Hour, ReplayTimeout: time.Second, MaxReplayEntries: 10},
+	}
+
+	dp, err := newDecoupleTracesProcessor(config, next, processortest.NewNopSettings(Type))
+	require.NoError(t, err)
+
+	// Data rejected with a permanent error is not persisted.
+	next.err = consumererror.NewPermanent(errors.New("rejected"))
+	dp.FunctionInvoked()
+	dp.queueData(context.Background(), newTestTraces("rejected"))
+	dp.FunctionFinished()
+	entries, err := dp.buffer.entries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// A transient export failure is persisted.
+	next.err = errors.New("backend unavailable")
+	dp.FunctionInvoked()
+	dp.queueData(context.Background(), newTestTraces("carried-over"))
+	dp.FunctionFinished()
+	require.Equal(t, 0, next.SpanCount())
+	entries, err = dp.buffer.entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, 1, dp.Pending())
+
+	// The persisted data is replayed once the backend is reachable again.
+	next.err = nil
+	dp.FunctionInvoked()
+	dp.FunctionFinished()
+	require.Len(t, next.AllTraces(), 1)
+	require.Equal(t, "carried-over", next.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
+	entries, err = dp.buffer.entries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.Equal(t, 0, dp.Pending())
+
+	require.NoError(t, dp.shutdown(context.Background()))
+}
+
+// blockingTracesConsumer blocks each export until the context is done.
+type blockingTracesConsumer struct {
+	consumertest.TracesSink
+	block bool
+}
+
+func (b *blockingTracesConsumer) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	if b.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return b.TracesSink.ConsumeTraces(ctx, td)
+}
+
+func TestRetryBufferReplayBudget(t *testing.T) {
+	lambdalifecycle.SetNotifier(&MockLifecycleNotifier{})
+	next := &blockingTracesConsumer{}
+	config := &Config{
+		MaxQueueSize: 10,
+		Workers:      1,
+		RetryBuffer:  RetryBufferConfig{Enabled: true, Directory: t.TempDir(), MaxSizeMiB: 1, MaxAge: time.Hour, ReplayTimeout: 100 * time.Millisecond, MaxReplayEntries: 2},
+	}
+	dp, err := newDecoupleTracesProcessor(config, next, processortest.NewNopSettings(Type))
+	require.NoError(t, err)
+	for _, name := range []string{"a", "b", "c"} {
+		payload, err := dp.consumer.marshal(newTestTraces(name))
+		require.NoError(t, err)
+		require.NoError(t, dp.buffer.store(payload))
+	}
+
+	// A backend that does not answer delays the end of the invocation by the replay timeout at most, and the
+	// entries are kept.
+	next.block = true
+	start := time.Now()
+	dp.FunctionInvoked()
+	dp.FunctionFinished()
+	require.Less(t, time.Since(start), time.Second)
+	require.Eventually(t, func() bool {
+		dp.lock.Lock()
+		defer dp.lock.Unlock()
+		return !dp.replaying()
+	}, time.Second, 10*time.Millisecond)
+	entries, err := dp.buffer.entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	// At most max_replay_entries entries are replayed in each invocation.
+	next.block = false
+	dp.FunctionInvoked()
+	dp.FunctionFinished()
+	require.Len(t, next.AllTraces(), 2)
+	entries, err = dp.buffer.entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	dp.FunctionInvoked()
+	dp.FunctionFinished()
+	require.Len(t, next.AllTraces(), 3)
+
+	require.NoError(t, dp.shutdown(context.Background()))
+}
@@ -118,13 +118,17 @@ func (r *telemetryAPIReceiver) createMetrics(e event) (pmetric.Metrics, error) {
 	return metrics, nil
 }
 
-// createInitSpan creates a trace span for the Lambda init phase.
+// createInitSpan creates a trace span for the Lambda init phase. The extension startup spans are added as its
+// children if the startup has already been reported.
 func (r *telemetryAPIReceiver) createInitSpan(e event) (ptrace.Traces, error) {
 	traces := ptrace.NewTraces()
 	rs := traces.ResourceSpans().AppendEmpty()
 	r.resource.CopyTo(rs.Resource())
-	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	ss := rs.ScopeSpans().AppendEmpty()
+	span := ss.Spans().AppendEmpty()
 
+	span.SetTraceID(r.initTraceID)
+	span.SetSpanID(r.initSpanID)
 	span.SetName("platform.init")
 	span.SetKind(ptrace.SpanKindInternal)
 	span.Attributes().PutBool(semconv.AttributeFaaSColdstart, true)
@@ -134,6 +138,9 @@ func (r *telemetryAPIReceiver) createInitSpan(e event) (ptrace.Traces, error) {
 	if record, ok := e.Record.(map[string]interface{}); ok {
 		setSpanStatus(span, record)
 	}
+	if r.startup != nil {
+		r.appendStartupSpans(ss.Spans(), *r.startup)
+	}
 	return traces, nil
 }
 
@@ -0,0 +1,162 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetryapireceiver // import "github.com/open-telemetry/opentelemetry-lambda/collector/receiver/telemetryapireceiver"
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.25.0"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+const (
+	postInvokeSpanName   = "extension.post_invoke"
+	postInvokeMetricName = "aws.lambda.extension.post_invoke.duration"
+	listenerAttribute    = "lambda.extension.listener"
+	// totalListener is the value of the listener attribute for the data point covering the whole post invoke phase.
+	totalListener = "total"
+)
+
+// postInvokeBounds are the histogram bucket boundaries, in milliseconds, of the post invoke duration.
+var postInvokeBounds = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// FunctionInvoked is a no-op, the receiver only registers as a lifecycle listener to report the invocation overhead.
+func (r *telemetryAPIReceiver) FunctionInvoked() {}
+
+// FunctionFinished is a no-op, the receiver only registers as a lifecycle listener to report the invocation overhead.
+func (r *telemetryAPIReceiver) FunctionFinished() {}
+
+// EnvironmentShutdown is a no-op, the receiver only registers as a lifecycle listener to report the invocation overhead.
+func (r *telemetryAPIReceiver) EnvironmentShutdown() {}
+
+// InvocationOverhead reports the time between platform.runtimeDone and the extension asking for the next event as
+// an extension.post_invoke span, with a child span per lifecycle listener, and as a histogram.
+func (r *telemetryAPIReceiver) InvocationOverhead(overhead lambdalifecycle.InvocationOverhead) {
+	ctx := context.Background()
+	if r.nextTraces != nil {
+		if err := r.nextTraces.ConsumeTraces(ctx, r.createPostInvokeSpans(overhead)); err != nil {
+			r.logger.Debug("Failed to consume post invoke spans", zap.Error(err))
+		}
+	}
+	if r.nextMetrics != nil {
+		if err := r.nextMetrics.ConsumeMetrics(ctx, r.createPostInvokeMetrics(overhead)); err != nil {
+			r.logger.Debug("Failed to consume post invoke metrics", zap.Error(err))
+		}
+	}
+}
+
+func (r *telemetryAPIReceiver) createPostInvokeSpans(overhead lambdalifecycle.InvocationOverhead) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	r.resource.CopyTo(rs.Resource())
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName(scopeName)
+
+	traceID := newTraceID()
+	parent := ss.Spans().AppendEmpty()
+	parent.SetTraceID(traceID)
+	parent.SetSpanID(newSpanID())
+	parent.SetName(postInvokeSpanName)
+	parent.SetKind(ptrace.SpanKindInternal)
+	parent.SetStartTimestamp(pcommon.NewTimestampFromTime(overhead.RuntimeDone))
+	parent.SetEndTimestamp(pcommon.NewTimestampFromTime(overhead.End))
+	if overhead.RequestID != "" {
+		parent.Attributes().PutStr(semconv.AttributeFaaSInvocationID, overhead.RequestID)
+	}
+
+	// Listeners are called one after the other once the runtime is done.
+	start := overhead.RuntimeDone
+	for _, l := range overhead.Listeners {
+		child := ss.Spans().AppendEmpty()
+		child.SetTraceID(traceID)
+		child.SetSpanID(newSpanID())
+		child.SetParentSpanID(parent.SpanID())
+		child.SetName(postInvokeSpanName + ".listener")
+		child.SetKind(ptrace.SpanKindInternal)
+		child.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		start = start.Add(l.Duration)
+		child.SetEndTimestamp(pcommon.NewTimestampFromTime(start))
+		child.Attributes().PutStr(listenerAttribute, l.Name)
+	}
+	return traces
+}
+
+func (r *telemetryAPIReceiver) createPostInvokeMetrics(overhead lambdalifecycle.InvocationOverhead) pmetric.Metrics {
+	metrics := pmetric.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	r.resource.CopyTo(rm.Resource())
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(scopeName)
+
+	m := sm.Metrics().AppendEmpty()
+	m.SetName(postInvokeMetricName)
+	m.SetDescription("Time the extension added after the runtime completed the invocation.")
+	m.SetUnit("ms")
+	hist := m.SetEmptyHistogram()
+	hist.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+
+	start := pcommon.NewTimestampFromTime(overhead.RuntimeDone)
+	end := pcommon.NewTimestampFromTime(overhead.End)
+	addPoint := func(listener string, d time.Duration) {
+		dp := hist.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(start)
+		dp.SetTimestamp(end)
+		dp.Attributes().PutStr(listenerAttribute, listener)
+		setHistogramValue(dp, float64(d)/float64(time.Millisecond))
+	}
+	addPoint(totalListener, overhead.End.Sub(overhead.RuntimeDone))
+	for _, l := range overhead.Listeners {
+		addPoint(l.Name, l.Duration)
+	}
+	return metrics
+}
+
+// setHistogramValue records a single value in the histogram data point.
+func setHistogramValue(dp pmetric.HistogramDataPoint, val float64) {
+	dp.ExplicitBounds().FromRaw(postInvokeBounds)
+	counts := make([]uint64, len(postInvokeBounds)+1)
+	bucket := len(postInvokeBounds)
+	for i, bound := range postInvokeBounds {
+		if val <= bound {
+			bucket = i
+			break
+		}
+	}
+	counts[bucket] = 1
+	dp.BucketCounts().FromRaw(counts)
+	dp.SetCount(1)
+	dp.SetSum(val)
+	dp.SetMin(val)
+	dp.SetMax(val)
+}
+
+func newTraceID() pcommon.TraceID {
+	var id pcommon.TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() pcommon.SpanID {
+	var id pcommon.SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
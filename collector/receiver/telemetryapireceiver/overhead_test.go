@@ -0,0 +1,90 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetryapireceiver // import "github.com/open-telemetry/opentelemetry-lambda/collector/receiver/telemetryapireceiver"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	semconv "go.opentelemetry.io/collector/semconv/v1.25.0"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+func TestInvocationOverhead(t *testing.T) {
+	r, err := newTelemetryAPIReceiver(&Config{}, receivertest.NewNopSettings(Type))
+	require.NoError(t, err)
+	traces := new(consumertest.TracesSink)
+	metrics := new(consumertest.MetricsSink)
+	r.registerTracesConsumer(traces)
+	r.registerMetricsConsumer(metrics)
+
+	runtimeDone := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.InvocationOverhead(lambdalifecycle.InvocationOverhead{
+		RequestID:   "request-1",
+		RuntimeDone: runtimeDone,
+		End:         runtimeDone.Add(120 * time.Millisecond),
+		Listeners: []lambdalifecycle.ListenerTiming{
+			{Name: "decouple", Duration: 100 * time.Millisecond},
+			{Name: "other", Duration: 15 * time.Millisecond},
+		},
+	})
+
+	require.Len(t, traces.AllTraces(), 1)
+	spans := traces.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 3, spans.Len())
+
+	parent := spans.At(0)
+	require.Equal(t, postInvokeSpanName, parent.Name())
+	require.Equal(t, pcommon.NewTimestampFromTime(runtimeDone), parent.StartTimestamp())
+	require.Equal(t, pcommon.NewTimestampFromTime(runtimeDone.Add(120*time.Millisecond)), parent.EndTimestamp())
+	invocationID, ok := parent.Attributes().Get(semconv.AttributeFaaSInvocationID)
+	require.True(t, ok)
+	require.Equal(t, "request-1", invocationID.Str())
+
+	decouple := spans.At(1)
+	require.Equal(t, parent.TraceID(), decouple.TraceID())
+	require.Equal(t, parent.SpanID(), decouple.ParentSpanID())
+	require.Equal(t, pcommon.NewTimestampFromTime(runtimeDone.Add(100*time.Millisecond)), decouple.EndTimestamp())
+	other := spans.At(2)
+	require.Equal(t, decouple.EndTimestamp(), other.StartTimestamp())
+	listener, ok := other.Attributes().Get(listenerAttribute)
+	require.True(t, ok)
+	require.Equal(t, "other", listener.Str())
+
+	require.Len(t, metrics.AllMetrics(), 1)
+	m := metrics.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+	require.Equal(t, postInvokeMetricName, m.Name())
+	dps := m.Histogram().DataPoints()
+	require.Equal(t, 3, dps.Len())
+	total, ok := dps.At(0).Attributes().Get(listenerAttribute)
+	require.True(t, ok)
+	require.Equal(t, totalListener, total.Str())
+	require.Equal(t, 120.0, dps.At(0).Sum())
+	// 120ms falls in the (100, 250] bucket.
+	require.Equal(t, uint64(1), dps.At(0).BucketCounts().At(6))
+}
+
+func TestInvocationOverheadWithoutConsumers(t *testing.T) {
+	r, err := newTelemetryAPIReceiver(&Config{}, receivertest.NewNopSettings(Type))
+	require.NoError(t, err)
+	require.NotPanics(t, func() {
+		r.InvocationOverhead(lambdalifecycle.InvocationOverhead{RuntimeDone: time.Now(), End: time.Now()})
+	})
+}
@@ -22,8 +22,10 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
 	"github.com/open-telemetry/opentelemetry-lambda/collector/receiver/telemetryapireceiver/internal/telemetryapi"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
@@ -53,6 +55,16 @@ type telemetryAPIReceiver struct {
 	// State management for init and invoke phases
 	initStartTime time.Time
 	invocations   map[string]invocationState
+
+	// The init span IDs are known upfront so that the extension startup spans can be its children, whichever is
+	// reported first.
+	initTraceID  pcommon.TraceID
+	initSpanID   pcommon.SpanID
+	startupMu    sync.Mutex
+	startup      *lambdalifecycle.ExtensionStartup
+	initReported bool
+	// degraded is the reason the receiver could not subscribe to the Telemetry API, if it could not.
+	degraded string
 }
 
 func newTelemetryAPIReceiver(
@@ -63,6 +75,8 @@ func newTelemetryAPIReceiver(
 		"AWS_LAMBDA_FUNCTION_MEMORY_SIZE": semconv.AttributeFaaSMaxMemory,
 		"AWS_LAMBDA_FUNCTION_VERSION":     semconv.AttributeFaaSVersion,
 		"AWS_REGION":                      semconv.AttributeFaaSInvokedRegion,
+		// Set by the user, the account ID returned on registration is used otherwise.
+		"AWS_ACCOUNT_ID": semconv.AttributeCloudAccountID,
 	}
 	r := pcommon.NewResource()
 	r.Attributes().PutStr(semconv.AttributeCloudProvider, semconv.AttributeCloudProviderAWS)
@@ -83,6 +97,12 @@ func newTelemetryAPIReceiver(
 		}
 	}
 
+	if _, ok := r.Attributes().Get(semconv.AttributeCloudAccountID); !ok {
+		if accountID := lambdalifecycle.AccountID(); accountID != "" {
+			r.Attributes().PutStr(semconv.AttributeCloudAccountID, accountID)
+		}
+	}
+
 	if envID, ok := os.LookupEnv("LOGZIO_ENV_ID"); ok {
 		r.Attributes().PutStr("env_id", envID)
 	}
@@ -92,6 +112,8 @@ func newTelemetryAPIReceiver(
 		logger:      set.Logger,
 		resource:    r,
 		invocations: make(map[string]invocationState),
+		initTraceID: newTraceID(),
+		initSpanID:  newSpanID(),
 	}, nil
 }
 
@@ -117,6 +139,11 @@ func (r *telemetryAPIReceiver) Start(ctx context.Context, host component.Host) e
 		}
 	}()
 
+	// Register with the lifecycle notifier to report the extension startup and the time it adds to each invocation.
+	if notifier := lambdalifecycle.GetNotifier(); notifier != nil {
+		notifier.AddListener(r)
+	}
+
 	apiClient, err := telemetryapi.NewClient(r.logger)
 	if err != nil {
 		return fmt.Errorf("failed to create telemetry api client: %w", err)
@@ -140,7 +167,11 @@ func (r *telemetryAPIReceiver) Start(ctx context.Context, host component.Host) e
 
 		err = apiClient.Subscribe(ctx, r.config.extensionID, eventTypes, bufferingCfg, destinationCfg)
 		if err != nil {
-			return fmt.Errorf("failed to subscribe to Telemetry API: %w", err)
+			// The collector keeps running for the other receivers, the degradation is reported once it has started.
+			r.logger.Warn("Failed to subscribe to Telemetry API, no telemetry will be received from it", zap.Error(err))
+			r.startupMu.Lock()
+			r.degraded = subscribeFailed
+			r.startupMu.Unlock()
 		}
 	}
 
@@ -189,9 +220,12 @@ func (r *telemetryAPIReceiver) httpHandler(w http.ResponseWriter, req *http.Requ
 			}
 		case telemetryapi.PlatformInitRuntimeDone:
 			if !r.initStartTime.IsZero() {
+				r.startupMu.Lock()
 				if traces, err := r.createInitSpan(e); err == nil {
 					_ = r.nextTraces.ConsumeTraces(ctx, traces)
 				}
+				r.initReported = true
+				r.startupMu.Unlock()
 				r.initStartTime = time.Time{} // Reset after use
 			}
 		case telemetryapi.PlatformStart:
@@ -17,6 +17,7 @@ package telemetryapireceiver // import "github.com/open-telemetry/opentelemetry-
 import (
 	"context"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 
@@ -25,6 +26,9 @@ import (
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/receiver/receivertest"
+	semconv "go.opentelemetry.io/collector/semconv/v1.25.0"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
 )
 
 func TestListenOnAddress(t *testing.T) {
@@ -120,3 +124,30 @@ func TestHandler(t *testing.T) {
 		})
 	}
 }
+
+// accountIDNotifier knows the account ID returned on registration.
+type accountIDNotifier struct{}
+
+func (accountIDNotifier) AddListener(lambdalifecycle.Listener) {}
+func (accountIDNotifier) AccountID() string                    { return "123456789012" }
+
+func TestResourceAccountID(t *testing.T) {
+	previous := lambdalifecycle.GetNotifier()
+	lambdalifecycle.SetNotifier(accountIDNotifier{})
+	defer lambdalifecycle.SetNotifier(previous)
+
+	t.Setenv("AWS_ACCOUNT_ID", "")
+	require.NoError(t, os.Unsetenv("AWS_ACCOUNT_ID"))
+	r, err := newTelemetryAPIReceiver(&Config{}, receivertest.NewNopSettings(Type))
+	require.NoError(t, err)
+	accountID, ok := r.resource.Attributes().Get(semconv.AttributeCloudAccountID)
+	require.True(t, ok, "the account ID returned on registration is used")
+	require.Equal(t, "123456789012", accountID.Str())
+
+	t.Setenv("AWS_ACCOUNT_ID", "012345678901")
+	r, err = newTelemetryAPIReceiver(&Config{}, receivertest.NewNopSettings(Type))
+	require.NoError(t, err)
+	accountID, ok = r.resource.Attributes().Get(semconv.AttributeCloudAccountID)
+	require.True(t, ok)
+	require.Equal(t, "012345678901", accountID.Str(), "the account ID set by the user is kept")
+}
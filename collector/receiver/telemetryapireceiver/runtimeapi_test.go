@@ -0,0 +1,83 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetryapireceiver // import "github.com/open-telemetry/opentelemetry-lambda/collector/receiver/telemetryapireceiver"
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle/runtimeapitest"
+)
+
+// TestRuntimeAPI runs the receiver against a fake Runtime API playing the initialization and an invocation.
+func TestRuntimeAPI(t *testing.T) {
+	server := runtimeapitest.NewServer(runtimeapitest.Script{
+		Init:   runtimeapitest.InitTelemetry(),
+		Events: []runtimeapitest.Event{runtimeapitest.Invoke("request-1")},
+	})
+	defer server.Close()
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", server.Addr)
+	t.Setenv("AWS_SAM_LOCAL", "true")
+	extensionID := server.Register("collector")
+
+	factory := NewFactory(extensionID)
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Port = freePort(t)
+	traces, logs, metrics := new(consumertest.TracesSink), new(consumertest.LogsSink), new(consumertest.MetricsSink)
+	ctx := context.Background()
+	r, err := factory.CreateTraces(ctx, receivertest.NewNopSettings(Type), cfg, traces)
+	require.NoError(t, err)
+	_, err = factory.CreateLogs(ctx, receivertest.NewNopSettings(Type), cfg, logs)
+	require.NoError(t, err)
+	_, err = factory.CreateMetrics(ctx, receivertest.NewNopSettings(Type), cfg, metrics)
+	require.NoError(t, err)
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	defer func() {
+		require.NoError(t, r.Shutdown(ctx))
+	}()
+
+	require.Len(t, server.Subscriptions(), 1)
+	require.Equal(t, extensionID, server.Subscriptions()[0].ExtensionID)
+	require.ElementsMatch(t, []string{platform, function, extension}, server.Subscriptions()[0].Types)
+
+	// Act as the extension waiting for the invocation.
+	req, err := http.NewRequest(http.MethodGet, "http://"+server.Addr+"/2020-01-01/extension/event/next", nil)
+	require.NoError(t, err)
+	req.Header.Set("Lambda-Extension-Identifier", extensionID)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.Eventually(t, func() bool {
+		return traces.SpanCount() == 2 && logs.LogRecordCount() == 1 && metrics.DataPointCount() > 0
+	}, 5*time.Second, 10*time.Millisecond, "the init and invoke spans, the function log and the report metrics are received")
+	require.Empty(t, server.DeliveryErrors())
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
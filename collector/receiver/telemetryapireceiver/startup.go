@@ -0,0 +1,134 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetryapireceiver // import "github.com/open-telemetry/opentelemetry-lambda/collector/receiver/telemetryapireceiver"
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+const (
+	degradedMetricName = "aws.lambda.extension.degraded"
+	reasonAttribute    = "reason"
+	// errorMessageAttribute describes the error of the configuration the extension fell back from.
+	errorMessageAttribute = "error.message"
+	// subscribeFailed is the degradation reason reported when the receiver cannot subscribe to the Telemetry API.
+	subscribeFailed = "Extension.SubscribeFailed"
+	// configInvalid is the degradation reason reported when the extension fell back to its built-in configuration.
+	configInvalid = "Extension.ConfigInvalid"
+
+	startupSpanName = "extension.startup"
+	// startupSpanPrefix is prepended to the name of the startup phases, e.g. "extension.startup.collector.start".
+	startupSpanPrefix = startupSpanName + "."
+)
+
+// ExtensionStarted reports the extension startup as an extension.startup span, with a child span per phase, under the
+// platform.init span. If the init span has not been reported yet, the startup spans are sent along with it.
+//
+// If the extension or the receiver could not use the Telemetry API, or if the extension fell back to its built-in
+// configuration, an aws.lambda.extension.degraded metric is reported with the reason.
+func (r *telemetryAPIReceiver) ExtensionStarted(startup lambdalifecycle.ExtensionStartup) {
+	r.startupMu.Lock()
+	defer r.startupMu.Unlock()
+	r.reportDegraded(startup)
+	if !r.initReported {
+		r.startup = &startup
+		return
+	}
+	if r.nextTraces == nil {
+		return
+	}
+
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	r.resource.CopyTo(rs.Resource())
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName(scopeName)
+	r.appendStartupSpans(ss.Spans(), startup)
+	if err := r.nextTraces.ConsumeTraces(context.Background(), traces); err != nil {
+		r.logger.Debug("Failed to consume extension startup spans", zap.Error(err))
+	}
+}
+
+// appendStartupSpans appends the extension startup spans as children of the platform.init span.
+func (r *telemetryAPIReceiver) appendStartupSpans(spans ptrace.SpanSlice, startup lambdalifecycle.ExtensionStartup) {
+	parent := spans.AppendEmpty()
+	parent.SetTraceID(r.initTraceID)
+	parent.SetSpanID(newSpanID())
+	parent.SetParentSpanID(r.initSpanID)
+	parent.SetName(startupSpanName)
+	parent.SetKind(ptrace.SpanKindInternal)
+	parent.SetStartTimestamp(pcommon.NewTimestampFromTime(startup.Start))
+	parent.SetEndTimestamp(pcommon.NewTimestampFromTime(startup.End))
+
+	for _, phase := range startup.Phases {
+		child := spans.AppendEmpty()
+		child.SetTraceID(r.initTraceID)
+		child.SetSpanID(newSpanID())
+		child.SetParentSpanID(parent.SpanID())
+		child.SetName(startupSpanPrefix + phase.Name)
+		child.SetKind(ptrace.SpanKindInternal)
+		child.SetStartTimestamp(pcommon.NewTimestampFromTime(phase.Start))
+		child.SetEndTimestamp(pcommon.NewTimestampFromTime(phase.End))
+	}
+}
+
+// reportDegraded reports the degradation of the extension, if any, as a gauge with one data point per reason. The
+// data point of a configuration fallback describes the error of the configuration.
+func (r *telemetryAPIReceiver) reportDegraded(startup lambdalifecycle.ExtensionStartup) {
+	var reasons []string
+	for _, reason := range []string{startup.Degraded, r.degraded} {
+		if reason != "" && !slices.Contains(reasons, reason) {
+			reasons = append(reasons, reason)
+		}
+	}
+	if startup.ConfigFallback != "" {
+		reasons = append(reasons, configInvalid)
+	}
+	if len(reasons) == 0 || r.nextMetrics == nil {
+		return
+	}
+
+	metrics := pmetric.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	r.resource.CopyTo(rm.Resource())
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(scopeName)
+	m := sm.Metrics().AppendEmpty()
+	m.SetName(degradedMetricName)
+	m.SetDescription("Whether the extension runs in a degraded mode, by reason.")
+	m.SetUnit("1")
+	gauge := m.SetEmptyGauge()
+	ts := pcommon.NewTimestampFromTime(startup.End)
+	for _, reason := range reasons {
+		dp := gauge.DataPoints().AppendEmpty()
+		dp.SetTimestamp(ts)
+		dp.SetIntValue(1)
+		dp.Attributes().PutStr(reasonAttribute, reason)
+		if reason == configInvalid {
+			dp.Attributes().PutStr(errorMessageAttribute, startup.ConfigFallback)
+		}
+	}
+	if err := r.nextMetrics.ConsumeMetrics(context.Background(), metrics); err != nil {
+		r.logger.Debug("Failed to consume extension degraded metric", zap.Error(err))
+	}
+}
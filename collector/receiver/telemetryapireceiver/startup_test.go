@@ -0,0 +1,136 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetryapireceiver // import "github.com/open-telemetry/opentelemetry-lambda/collector/receiver/telemetryapireceiver"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+
+	"github.com/open-telemetry/opentelemetry-lambda/collector/lambdalifecycle"
+)
+
+func testStartup(start time.Time) lambdalifecycle.ExtensionStartup {
+	return lambdalifecycle.ExtensionStartup{
+		Start: start,
+		End:   start.Add(30 * time.Millisecond),
+		Phases: []lambdalifecycle.StartupPhase{
+			{Name: "extension.register", Start: start, End: start.Add(10 * time.Millisecond)},
+			{Name: "collector.start", Start: start.Add(10 * time.Millisecond), End: start.Add(30 * time.Millisecond)},
+		},
+	}
+}
+
+func requireStartupSpans(t *testing.T, r *telemetryAPIReceiver, spans ptrace.SpanSlice) {
+	require.Equal(t, 3, spans.Len())
+	startup := spans.At(0)
+	require.Equal(t, startupSpanName, startup.Name())
+	require.Equal(t, r.initTraceID, startup.TraceID())
+	require.Equal(t, r.initSpanID, startup.ParentSpanID())
+	require.Equal(t, "extension.startup.extension.register", spans.At(1).Name())
+	require.Equal(t, startup.SpanID(), spans.At(1).ParentSpanID())
+	require.Equal(t, "extension.startup.collector.start", spans.At(2).Name())
+	require.Equal(t, r.initTraceID, spans.At(2).TraceID())
+}
+
+func TestExtensionStartedBeforeInit(t *testing.T) {
+	r, err := newTelemetryAPIReceiver(&Config{}, receivertest.NewNopSettings(Type))
+	require.NoError(t, err)
+	sink := new(consumertest.TracesSink)
+	r.registerTracesConsumer(sink)
+
+	start := time.Now()
+	r.ExtensionStarted(testStartup(start))
+	require.Empty(t, sink.AllTraces(), "startup spans are held until the init span is reported")
+
+	r.initStartTime = start.Add(-time.Second)
+	traces, err := r.createInitSpan(event{Time: start.Add(time.Second).Format(time.RFC3339), Type: "platform.initRuntimeDone"})
+	require.NoError(t, err)
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 4, spans.Len())
+	initSpan := spans.At(0)
+	require.Equal(t, "platform.init", initSpan.Name())
+	require.Equal(t, r.initSpanID, initSpan.SpanID())
+	startup := ptrace.NewSpanSlice()
+	for i := 1; i < spans.Len(); i++ {
+		spans.At(i).CopyTo(startup.AppendEmpty())
+	}
+	requireStartupSpans(t, r, startup)
+}
+
+func TestExtensionStartedAfterInit(t *testing.T) {
+	r, err := newTelemetryAPIReceiver(&Config{}, receivertest.NewNopSettings(Type))
+	require.NoError(t, err)
+	sink := new(consumertest.TracesSink)
+	r.registerTracesConsumer(sink)
+	r.initReported = true
+
+	r.ExtensionStarted(testStartup(time.Now()))
+	require.Len(t, sink.AllTraces(), 1)
+	requireStartupSpans(t, r, sink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans())
+}
+
+func TestExtensionStartedDegraded(t *testing.T) {
+	r, err := newTelemetryAPIReceiver(&Config{}, receivertest.NewNopSettings(Type))
+	require.NoError(t, err)
+	sink := new(consumertest.MetricsSink)
+	r.registerMetricsConsumer(sink)
+
+	r.ExtensionStarted(testStartup(time.Now()))
+	require.Empty(t, sink.AllMetrics(), "no degradation is reported for a normal startup")
+
+	r.degraded = subscribeFailed
+	startup := testStartup(time.Now())
+	startup.Degraded = "Extension.ListenerFailed"
+	r.ExtensionStarted(startup)
+	require.Len(t, sink.AllMetrics(), 1)
+	m := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+	require.Equal(t, degradedMetricName, m.Name())
+	dps := m.Gauge().DataPoints()
+	require.Equal(t, 2, dps.Len())
+	reason, ok := dps.At(0).Attributes().Get(reasonAttribute)
+	require.True(t, ok)
+	require.Equal(t, "Extension.ListenerFailed", reason.Str())
+	reason, ok = dps.At(1).Attributes().Get(reasonAttribute)
+	require.True(t, ok)
+	require.Equal(t, subscribeFailed, reason.Str())
+	require.Equal(t, int64(1), dps.At(1).IntValue())
+}
+
+func TestExtensionStartedConfigFallback(t *testing.T) {
+	r, err := newTelemetryAPIReceiver(&Config{}, receivertest.NewNopSettings(Type))
+	require.NoError(t, err)
+	sink := new(consumertest.MetricsSink)
+	r.registerMetricsConsumer(sink)
+
+	startup := testStartup(time.Now())
+	startup.ConfigFallback = "invalid collector configuration: 'exporters' unknown type"
+	r.ExtensionStarted(startup)
+	require.Len(t, sink.AllMetrics(), 1)
+	m := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+	require.Equal(t, degradedMetricName, m.Name())
+	dps := m.Gauge().DataPoints()
+	require.Equal(t, 1, dps.Len())
+	reason, ok := dps.At(0).Attributes().Get(reasonAttribute)
+	require.True(t, ok)
+	require.Equal(t, configInvalid, reason.Str())
+	message, ok := dps.At(0).Attributes().Get(errorMessageAttribute)
+	require.True(t, ok)
+	require.Equal(t, startup.ConfigFallback, message.Str())
+}